@@ -20,34 +20,249 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Modes of the reconciliation of AWSIAMProvision.
+const (
+	// ModeEnforce - the changes are applied to AWS IAM.
+	ModeEnforce = "Enforce"
+	// ModeObserve - the changes are computed and published in the status without mutating AWS IAM.
+	ModeObserve = "Observe"
+)
+
+// Backends which provision the AWS IAM resources of AWSIAMProvision.
+const (
+	// BackendACK - the roles and policies are provisioned by the ACK iam-controller through its Role and Policy CRs.
+	BackendACK = "ACK"
+	// BackendIAM - the roles and policies are provisioned by the operator through the AWS IAM API.
+	BackendIAM = "IAM"
+)
+
+// Types of the source of the EKS cluster.
+const (
+	// ClusterSourceCAPA - the AWSManagedControlPlane of Cluster API.
+	ClusterSourceCAPA = "CAPA"
+	// ClusterSourceEKS - the EKS DescribeCluster API.
+	ClusterSourceEKS = "EKS"
+	// ClusterSourceOIDC - the OIDC provider set explicitly in the spec.
+	ClusterSourceOIDC = "OIDC"
+)
+
 // AWSIAMProvisionSpec defines the desired state of AWSIAMProvision.
+// +kubebuilder:validation:XValidation:rule="has(self.backend) == has(oldSelf.backend) && (!has(self.backend) || self.backend == oldSelf.backend)",message="spec.backend can not be changed, the AWS IAM resources of the previous backend are not migrated"
 type AWSIAMProvisionSpec struct {
+	// Addons - the add-ons whose role and policy bundles of the catalog embedded into the operator are provisioned
+	// in addition to the roles and policies of the spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Addons []AddonReference `json:"addons,omitempty"`
+	// Backend - IAM provisions the roles and policies through the AWS IAM API, ACK emits the Role and Policy CRs
+	// of the ACK iam-controller owned by the AWSIAMProvision. Overrides the backend configured at the operator level.
+	// It can not be changed, the roles and policies provisioned by one backend are not migrated to the other one.
+	// +kubebuilder:validation:Enum=IAM;ACK
+	// +optional
+	Backend string `json:"backend,omitempty"`
+	// ClusterSource - the source of the OIDC provider of the EKS cluster,
+	// the AWSManagedControlPlane of Cluster API by default.
+	// +optional
+	ClusterSource *ClusterSource `json:"clusterSource,omitempty"`
 	// EKSClusterName - target EKS cluster name provisioned by Cluster API.
-	EKSClusterName string `json:"eksClusterName"`
+	// It is required for AWSIAMProvision and set per cluster for the template of AWSIAMProvisionFleet.
+	// +optional
+	EKSClusterName string `json:"eksClusterName,omitempty"`
 	// Frequency - AWS IAM resources synchronization frequency.
 	// It is not recommended to set values below 30s to avoid being blocked by the AWS API.
 	Frequency *metav1.Duration `json:"frequency,omitempty"`
+	// KubeconfigSecretRef - the Secret in the namespace of the CR with the kubeconfig of the workload cluster
+	// which the ServiceAccounts of the roles are bound in. By default, the `<cluster>-kubeconfig` Secret
+	// generated by Cluster API for the Cluster of the AWSManagedControlPlane.
+	// +optional
+	KubeconfigSecretRef *KubeconfigSecretReference `json:"kubeconfigSecretRef,omitempty"`
+	// Mode - Enforce applies the changes to AWS IAM, Observe only computes them and publishes
+	// them in `status.pendingActions`. Overrides the mode configured at the operator level.
+	// Switching to Enforce applies exactly the plan observed in `status.pendingActions`: the plan is recomputed
+	// and applied only if it is the same, otherwise nothing is applied until the plan is observed again.
+	// +kubebuilder:validation:Enum=Enforce;Observe
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// NameTemplate - Golang template of the IAM role and policy names, e.g. `{{ .ClusterName }}-{{ .Name }}`.
+	// Overrides the naming scheme configured at the operator level.
+	// Supported placeholders: `{{ .ClusterName }}`, `{{ .Namespace }}`, `{{ .Name }}`.
+	NameTemplate *string `json:"nameTemplate,omitempty"`
+	// Path - IAM path of the provisioned roles and policies, e.g. `/staging/`.
+	// Overrides the path prefix configured at the operator level.
+	// Several operator installations sharing one AWS account should use different paths to be isolated.
+	// +kubebuilder:validation:Pattern=`^/([\x21-\x7E]+/)?$`
+	Path *string `json:"path,omitempty"`
 	// Region for AWS config authentication.
 	Region string `json:"region"`
 	// Policies - map of policies with specifications.
 	Policies map[string]AWSIAMProvisionPolicy `json:"policies,omitempty"`
 	// Roles - map of roles with specifications.
 	Roles map[string]AWSIAMProvisionRole `json:"roles,omitempty"`
+	// Variables - user-defined values of the document templates, available as `{{ .Variables.<name> }}`.
+	// They take precedence over the values of `variablesFrom`.
+	// +optional
+	Variables map[string]string `json:"variables,omitempty"`
+	// VariablesFrom - ConfigMaps and Secrets in the namespace of the CR whose data is merged into the variables
+	// of the document templates in order, the latter sources take precedence.
+	// +optional
+	VariablesFrom []VariablesSource `json:"variablesFrom,omitempty"`
+}
+
+// ClusterSource defines where the OIDC provider of the EKS cluster is taken from.
+// +kubebuilder:validation:XValidation:rule="self.type != 'OIDC' || has(self.oidc)",message="oidc must be set for the OIDC type"
+type ClusterSource struct {
+	// Type - CAPA reads the AWSManagedControlPlane `eksClusterName` in the namespace of the CR,
+	// EKS describes the EKS cluster `eksClusterName` in `region`, OIDC takes the OIDC provider from `oidc`.
+	// +kubebuilder:validation:Enum=CAPA;EKS;OIDC
+	Type string `json:"type"`
+	// OIDC - the OIDC provider of the cluster for the OIDC type.
+	// +optional
+	OIDC *OIDCProviderSource `json:"oidc,omitempty"`
+}
+
+// OIDCProviderSource defines the IAM OIDC provider of the EKS cluster.
+// +kubebuilder:validation:XValidation:rule="has(self.providerARN) != has(self.issuerURL)",message="exactly one of providerARN or issuerURL must be set"
+type OIDCProviderSource struct {
+	// IssuerURL - the OIDC issuer of the cluster, e.g. `https://oidc.eks.eu-central-1.amazonaws.com/id/EXAMPLE`,
+	// the ARN of the OIDC provider is derived in the AWS account of the CR.
+	// +optional
+	IssuerURL string `json:"issuerURL,omitempty"`
+	// ProviderARN - the ARN of the IAM OIDC provider of the cluster.
+	// +optional
+	ProviderARN string `json:"providerARN,omitempty"`
+}
+
+// KubeconfigSecretReference defines the Secret with the kubeconfig of the workload cluster.
+type KubeconfigSecretReference struct {
+	Name string `json:"name"`
+	// Key - the key of the kubeconfig in the Secret, `value` by default as in the Secrets of Cluster API.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// VariablesSource defines the ConfigMap or Secret which the variables of the document templates are read from.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapRef) != has(self.secretRef)",message="exactly one of configMapRef or secretRef must be set"
+type VariablesSource struct {
+	// ConfigMapRef - the ConfigMap in the namespace of the CR.
+	// +optional
+	ConfigMapRef *VariablesSourceReference `json:"configMapRef,omitempty"`
+	// SecretRef - the Secret in the namespace of the CR.
+	// +optional
+	SecretRef *VariablesSourceReference `json:"secretRef,omitempty"`
+}
+
+// VariablesSourceReference defines the name of the ConfigMap or Secret.
+type VariablesSourceReference struct {
+	Name string `json:"name"`
+	// Optional - the object is skipped if it does not exist, otherwise the reconciliation fails.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// AddonReference enables the bundle of the add-on from the catalog embedded into the operator.
+type AddonReference struct {
+	// Name - the name of the add-on in the catalog, e.g. `ebs-csi`.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Parameters - the values of the parameters of the bundle.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// ServiceAccount - the `<namespace>/<name>` of the ServiceAccount of the add-on allowed to assume the role,
+	// the default ServiceAccount of the bundle if not set.
+	// +kubebuilder:validation:Pattern=`^[^/]+/[^/]+$`
+	// +optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// Version - the version of the bundle, e.g. `v1`, the latest version of the catalog if not set.
+	// +optional
+	Version string `json:"version,omitempty"`
 }
 
 // AWSIAMProvisionStatus defines the observed state of AWSIAMProvision.
 type AWSIAMProvisionStatus struct {
-	Message         string                        `json:"message,omitempty"`
-	LastUpdatedTime *metav1.Time                  `json:"lastUpdatedTime,omitempty"`
-	Phase           string                        `json:"phase,omitempty"`
-	Policies        []AWSIAMProvisionStatusPolicy `json:"policies,omitempty"`
-	Roles           []AWSIAMProvisionStatusRole   `json:"roles,omitempty"`
+	// Addons - the versions of the add-on bundles applied by the last successful sync.
+	// +optional
+	Addons []AWSIAMProvisionStatusAddon `json:"addons,omitempty"`
+	// Conditions - latest available observations of the AWSIAMProvision state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// DocumentsHash - the hash of the rendered documents of the roles and policies synced by the last successful sync.
+	// A change applied while the documents are unchanged is reported as a drift.
+	// +optional
+	DocumentsHash   string       `json:"documentsHash,omitempty"`
+	Message         string       `json:"message,omitempty"`
+	LastUpdatedTime *metav1.Time `json:"lastUpdatedTime,omitempty"`
+	// ObservedGeneration - the generation of the spec which was fully synced with AWS IAM.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// PendingActions - changes of AWS IAM resources computed in the Observe mode,
+	// they are applied after switching to the Enforce mode.
+	// +optional
+	PendingActions []AWSIAMProvisionPendingAction `json:"pendingActions,omitempty"`
+	// PlanHash - the hash of the pending actions and the rendered documents observed in the Observe mode.
+	// The Enforce mode applies the plan only if the recomputed plan has the same hash.
+	// +optional
+	PlanHash string `json:"planHash,omitempty"`
+	// Phase - summary of the conditions, kept for backward compatibility.
+	Phase    string                        `json:"phase,omitempty"`
+	Policies []AWSIAMProvisionStatusPolicy `json:"policies,omitempty"`
+	// PolicyTemplates - the revisions of the IAMPolicyTemplates applied by the last successful sync.
+	// +optional
+	PolicyTemplates []AWSIAMProvisionStatusPolicyTemplate `json:"policyTemplates,omitempty"`
+	Roles           []AWSIAMProvisionStatusRole           `json:"roles,omitempty"`
+	// ServiceAccounts - the ServiceAccounts of the workload cluster bound to the roles.
+	// +optional
+	ServiceAccounts []AWSIAMProvisionStatusServiceAccount `json:"serviceAccounts,omitempty"`
+}
+
+// AWSIAMProvisionStatusAddon defines the applied version of the add-on bundle.
+type AWSIAMProvisionStatusAddon struct {
+	Name string `json:"name"`
+	// Role - the name of the role of the add-on.
+	Role    string `json:"role"`
+	Version string `json:"version"`
+}
+
+// AWSIAMProvisionStatusPolicyTemplate defines the applied revision of the IAMPolicyTemplate.
+type AWSIAMProvisionStatusPolicyTemplate struct {
+	Name string `json:"name"`
+	// Revision - the generation of the IAMPolicyTemplate.
+	Revision int64 `json:"revision"`
+}
+
+// AWSIAMProvisionStatusServiceAccount defines the ServiceAccount of the workload cluster bound to a role.
+type AWSIAMProvisionStatusServiceAccount struct {
+	// Created - the ServiceAccount was created by the operator, so it is deleted on unbinding,
+	// otherwise only the annotation is removed.
+	// +optional
+	Created   bool   `json:"created,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// RoleARN - the ARN of the role the ServiceAccount is annotated with.
+	RoleARN string `json:"roleARN"`
+}
+
+// AWSIAMProvisionPendingAction defines a change of AWS IAM resource which is not applied yet.
+type AWSIAMProvisionPendingAction struct {
+	// Action - one of Create, Update, Delete, Attach, Detach or Tag.
+	Action string `json:"action"`
+	// Name - name of the role or policy.
+	Name string `json:"name"`
+	// ResourceType - Role or Policy.
+	ResourceType string `json:"resourceType"`
+	// Role - the role which the policy is attached to or detached from.
+	// +optional
+	Role string `json:"role,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="MODE",type=string,JSONPath=`.spec.mode`,priority=1
 // +kubebuilder:printcolumn:name="LAST-UPDATED-TIME",type=string,JSONPath=".status.lastUpdatedTime"
+// +kubebuilder:validation:XValidation:rule="has(self.spec) && has(self.spec.eksClusterName)",message="spec.eksClusterName must be set"
 
 // AWSIAMProvision is the Schema for the awsiamprovisions API.
 type AWSIAMProvision struct {
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels of the AWSIAMProvision CRs generated for the clusters.
+const (
+	// LabelCluster - the name of the AWSManagedControlPlane or the Cluster the AWSIAMProvision is generated for.
+	LabelCluster = "iam.aws.edenlab.io/cluster"
+	// LabelFleet - the name of the AWSIAMProvisionFleet which generated the AWSIAMProvision.
+	LabelFleet = "iam.aws.edenlab.io/fleet"
+)
+
+// AWSIAMProvisionFleetSpec defines the desired state of AWSIAMProvisionFleet.
+type AWSIAMProvisionFleetSpec struct {
+	// ClusterSelector - the labels of the AWSManagedControlPlanes in the namespace of the fleet
+	// which the AWSIAMProvision is generated for.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+	// Template - the spec of the AWSIAMProvision of every cluster, `eksClusterName` is set to the name
+	// of the AWSManagedControlPlane. The names of the IAM roles and policies are rendered
+	// by `{{ .ClusterName }}-{{ .Name }}` unless `nameTemplate` is set.
+	Template AWSIAMProvisionSpec `json:"template"`
+}
+
+// AWSIAMProvisionFleetStatus defines the observed state of AWSIAMProvisionFleet.
+type AWSIAMProvisionFleetStatus struct {
+	// Clusters - the clusters matching the selector and the state of their AWSIAMProvision CRs.
+	// +optional
+	Clusters []AWSIAMProvisionFleetStatusCluster `json:"clusters,omitempty"`
+	// ObservedGeneration - the generation of the spec which the AWSIAMProvision CRs were generated from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Ready - the number of the clusters whose AWSIAMProvision is ready.
+	Ready int32 `json:"ready"`
+	// Total - the number of the clusters matching the selector.
+	Total int32 `json:"total"`
+}
+
+// AWSIAMProvisionFleetStatusCluster defines the state of the AWSIAMProvision of a cluster.
+type AWSIAMProvisionFleetStatusCluster struct {
+	// AWSIAMProvision - the name of the generated AWSIAMProvision.
+	AWSIAMProvision string `json:"awsIAMProvision"`
+	Message         string `json:"message,omitempty"`
+	// Name - the name of the AWSManagedControlPlane of the fleet or the Cluster of the template.
+	Name  string `json:"name"`
+	Phase string `json:"phase,omitempty"`
+	// Ready - the status of the Ready condition of the AWSIAMProvision.
+	Ready metav1.ConditionStatus `json:"ready,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type=integer,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="TOTAL",type=integer,JSONPath=`.status.total`
+
+// AWSIAMProvisionFleet is the Schema for the awsiamprovisionfleets API.
+type AWSIAMProvisionFleet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWSIAMProvisionFleetSpec   `json:"spec,omitempty"`
+	Status AWSIAMProvisionFleetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AWSIAMProvisionFleetList contains a list of AWSIAMProvisionFleet.
+type AWSIAMProvisionFleetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWSIAMProvisionFleet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWSIAMProvisionFleet{}, &AWSIAMProvisionFleetList{})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AnnotationTemplates - the comma-separated names of the AWSIAMProvisionTemplates in the namespace
+	// of the Cluster which are instantiated for it regardless of its ClusterClass.
+	AnnotationTemplates = "iam.aws.edenlab.io/templates"
+	// LabelTemplate - the name of the AWSIAMProvisionTemplate which generated the AWSIAMProvision.
+	LabelTemplate = "iam.aws.edenlab.io/template"
+)
+
+// AWSIAMProvisionTemplateSpec defines the desired state of AWSIAMProvisionTemplate.
+type AWSIAMProvisionTemplateSpec struct {
+	// ClusterClassName - the ClusterClass of the Cluster API Clusters in the namespace of the template
+	// which the AWSIAMProvision is generated for. The other Clusters opt in by the
+	// `iam.aws.edenlab.io/templates` annotation.
+	// +optional
+	ClusterClassName string `json:"clusterClassName,omitempty"`
+	// Template - the spec of the AWSIAMProvision of every Cluster, `eksClusterName` is set to the name
+	// of the AWSManagedControlPlane of the Cluster. The names of the IAM roles and policies are rendered
+	// by `{{ .ClusterName }}-{{ .Name }}` unless `nameTemplate` is set.
+	Template AWSIAMProvisionSpec `json:"template"`
+}
+
+// AWSIAMProvisionTemplateStatus defines the observed state of AWSIAMProvisionTemplate.
+type AWSIAMProvisionTemplateStatus struct {
+	// Clusters - the Clusters the template is instantiated for and the state of their AWSIAMProvision CRs.
+	// +optional
+	Clusters []AWSIAMProvisionFleetStatusCluster `json:"clusters,omitempty"`
+	// ObservedGeneration - the generation of the spec which the AWSIAMProvision CRs were generated from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Ready - the number of the Clusters whose AWSIAMProvision is ready.
+	Ready int32 `json:"ready"`
+	// Total - the number of the Clusters the template is instantiated for.
+	Total int32 `json:"total"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTERCLASS",type=string,JSONPath=`.spec.clusterClassName`
+// +kubebuilder:printcolumn:name="READY",type=integer,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="TOTAL",type=integer,JSONPath=`.status.total`
+
+// AWSIAMProvisionTemplate is the Schema for the awsiamprovisiontemplates API.
+type AWSIAMProvisionTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWSIAMProvisionTemplateSpec   `json:"spec,omitempty"`
+	Status AWSIAMProvisionTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AWSIAMProvisionTemplateList contains a list of AWSIAMProvisionTemplate.
+type AWSIAMProvisionTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWSIAMProvisionTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWSIAMProvisionTemplate{}, &AWSIAMProvisionTemplateList{})
+}
@@ -0,0 +1,37 @@
+package v1alpha1
+
+// Condition types of AWSIAMProvision and its roles and policies.
+const (
+	// ConditionTypeControlPlaneReady - the target cluster exists and is ready according to its cluster source.
+	ConditionTypeControlPlaneReady = "ControlPlaneReady"
+	// ConditionTypeCredentialsValid - the AWS credentials of the operator are valid for the region.
+	ConditionTypeCredentialsValid = "CredentialsValid"
+	// ConditionTypeDrifted - the remote state diverged from the spec which had already been synced
+	// and the drift was corrected.
+	ConditionTypeDrifted = "Drifted"
+	// ConditionTypeReady - the summary condition, all the other conditions are satisfied.
+	ConditionTypeReady = "Ready"
+	// ConditionTypeSynced - the current generation of the spec is synced with AWS IAM.
+	ConditionTypeSynced = "Synced"
+)
+
+// Condition reasons of AWSIAMProvision and its roles and policies.
+const (
+	ConditionReasonACKCondition         = "ACKCondition"
+	ConditionReasonACKNotSynced         = "ACKNotSynced"
+	ConditionReasonConflict             = "Conflict"
+	ConditionReasonControlPlaneNotFound = "ControlPlaneNotFound"
+	ConditionReasonControlPlaneNotReady = "ControlPlaneNotReady"
+	ConditionReasonControlPlaneReady    = "ControlPlaneReady"
+	ConditionReasonCredentialsInvalid   = "CredentialsInvalid"
+	ConditionReasonCredentialsValid     = "CredentialsValid"
+	ConditionReasonDeleting             = "Deleting"
+	ConditionReasonDriftCorrected       = "DriftCorrected"
+	ConditionReasonNoDrift              = "NoDrift"
+	ConditionReasonPendingActions       = "PendingActions"
+	ConditionReasonPlanChanged          = "PlanChanged"
+	ConditionReasonProvisioning         = "Provisioning"
+	ConditionReasonReady                = "Ready"
+	ConditionReasonSyncFailed           = "SyncFailed"
+	ConditionReasonSynced               = "Synced"
+)
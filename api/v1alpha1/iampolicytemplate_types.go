@@ -0,0 +1,86 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IAMPolicyTemplateSpec defines the parameterized document shared by the AWSIAMProvision CRs.
+// +kubebuilder:validation:XValidation:rule="has(self.policyDocument) != has(self.statements)",message="exactly one of policyDocument or statements must be set"
+type IAMPolicyTemplateSpec struct {
+	// Parameters - the parameters of the document, they are available as `{{ .Parameters.<name> }}`
+	// in addition to the placeholders of the AWSIAMProvision documents.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Parameters []IAMPolicyTemplateParameter `json:"parameters,omitempty"`
+	// PolicyDocument - the document template, the same as `policyDocument` of the AWSIAMProvision policies.
+	// +optional
+	PolicyDocument *string `json:"policyDocument,omitempty"`
+	// Statements - the structured statements, the same as `statements` of the AWSIAMProvision policies.
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Statements []PolicyStatement `json:"statements,omitempty"`
+}
+
+// IAMPolicyTemplateParameter defines a parameter of the document.
+type IAMPolicyTemplateParameter struct {
+	// Default - the value of the parameter if it is not set by the reference, the parameter is required without it.
+	// +optional
+	Default *string `json:"default,omitempty"`
+	// Description - the human-readable description of the parameter.
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+}
+
+// PolicyTemplateReference refers to the IAMPolicyTemplate rendered with the parameter values.
+type PolicyTemplateReference struct {
+	// Name - the name of the cluster-scoped IAMPolicyTemplate.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Parameters - the values of the parameters of the template.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="REVISION",type=integer,JSONPath=`.metadata.generation`
+
+// IAMPolicyTemplate is the Schema for the iampolicytemplates API.
+type IAMPolicyTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IAMPolicyTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IAMPolicyTemplateList contains a list of IAMPolicyTemplate.
+type IAMPolicyTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IAMPolicyTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IAMPolicyTemplate{}, &IAMPolicyTemplateList{})
+}
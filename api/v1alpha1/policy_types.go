@@ -14,6 +14,7 @@ import (
 // For more information about managed policies, refer to Managed policies and
 // inline policies (https://docs.aws.amazon.com/IAM/latest/UserGuide/policies-managed-vs-inline.html)
 // in the IAM User Guide.
+// +kubebuilder:validation:XValidation:rule="[has(self.policyDocument), has(self.statements), has(self.templateRef)].filter(x, x).size() == 1",message="exactly one of policyDocument, statements or templateRef must be set"
 type PolicySpec struct {
 	// The friendly name of the policy.
 	//
@@ -51,8 +52,15 @@ type PolicySpec struct {
 	//   - The special characters tab (\u0009), line feed (\u000A), and carriage
 	//     return (\u000D)
 	//
-	// +kubebuilder:validation:Required
-	PolicyDocument *string `json:"policyDocument"`
+	// The document is a Golang template rendered with the values of the cluster, the account
+	// and the ARNs of the roles of the AWSIAMProvision. It is required unless `statements` or `templateRef` is set.
+	// +optional
+	PolicyDocument *string `json:"policyDocument,omitempty"`
+	// Statements - the structured statements serialized by the operator into the policy document,
+	// an alternative to `policyDocument`. The string values support the same template placeholders.
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Statements []PolicyStatement `json:"statements,omitempty"`
 	// A list of tags that you want to attach to the new IAM customer managed policy.
 	// Each tag consists of a key name and an associated value. For more information
 	// about tagging, see Tagging IAM resources (https://docs.aws.amazon.com/IAM/latest/UserGuide/id_tags.html)
@@ -61,6 +69,58 @@ type PolicySpec struct {
 	// If any one of the tags is invalid or if you exceed the allowed maximum number
 	// of tags, then the entire request fails and the resource is not created.
 	Tags []*Tag `json:"tags,omitempty"`
+	// TemplateRef - the IAMPolicyTemplate rendered into the policy document, an alternative to `policyDocument`.
+	// +optional
+	TemplateRef *PolicyTemplateReference `json:"templateRef,omitempty"`
+}
+
+// PolicyStatement defines a statement of the policy document.
+// +kubebuilder:validation:XValidation:rule="has(self.action) != has(self.notAction)",message="exactly one of action or notAction must be set"
+type PolicyStatement struct {
+	// Action - the actions allowed or denied by the statement.
+	// +optional
+	Action []string `json:"action,omitempty"`
+	// Condition - the conditions of the statement.
+	// +optional
+	Condition []PolicyCondition `json:"condition,omitempty"`
+	// Effect - whether the statement allows or denies the actions.
+	// +kubebuilder:validation:Enum=Allow;Deny
+	// +kubebuilder:default=Allow
+	// +optional
+	Effect string `json:"effect,omitempty"`
+	// NotAction - the actions excluded from the statement.
+	// +optional
+	NotAction []string `json:"notAction,omitempty"`
+	// Principal - the principals of the statement, only the resource-based policies have them.
+	// +optional
+	Principal []PolicyPrincipal `json:"principal,omitempty"`
+	// Resource - the resources of the statement, e.g. `*` or the ARNs.
+	// +optional
+	Resource []string `json:"resource,omitempty"`
+	// Sid - the optional identifier of the statement.
+	// +optional
+	Sid string `json:"sid,omitempty"`
+}
+
+// PolicyPrincipal defines a principal of a statement of the policy document.
+type PolicyPrincipal struct {
+	// Identifiers - the ARNs of the AWS principals, the names of the services or the federated identity providers.
+	// +kubebuilder:validation:MinItems=1
+	Identifiers []string `json:"identifiers"`
+	// Type - the type of the principal.
+	// +kubebuilder:validation:Enum=AWS;Service;Federated;CanonicalUser
+	Type string `json:"type"`
+}
+
+// PolicyCondition defines a condition of a statement of the policy document.
+type PolicyCondition struct {
+	// Test - the condition operator, e.g. `StringEquals` or `ArnLike`.
+	Test string `json:"test"`
+	// Values - the values of the condition key.
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
+	// Variable - the condition key, e.g. `aws:SourceAccount`.
+	Variable string `json:"variable"`
 }
 
 // PolicyStatus defines the observed state of Policy
@@ -95,8 +155,13 @@ type AWSIAMProvisionPolicy struct {
 
 // AWSIAMProvisionStatusPolicy defines the observed state of AWSIAMProvision's policies.
 type AWSIAMProvisionStatusPolicy struct {
-	Name    *string      `json:"name,omitempty"`
-	Message string       `json:"message,omitempty"`
-	Phase   string       `json:"phase,omitempty"`
-	Status  PolicyStatus `json:"status,omitempty"`
+	// Conditions - latest available observations of the policy state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	Name       *string            `json:"name,omitempty"`
+	Message    string             `json:"message,omitempty"`
+	Phase      string             `json:"phase,omitempty"`
+	Status     PolicyStatus       `json:"status,omitempty"`
 }
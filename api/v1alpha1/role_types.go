@@ -8,6 +8,7 @@ import (
 //
 // Contains information about an IAM role. This structure is returned as a response
 // element in several API operations that interact with roles.
+// +kubebuilder:validation:XValidation:rule="[has(self.assumeRolePolicyDocument), has(self.trust), has(self.trustTemplateRef)].filter(x, x).size() == 1",message="exactly one of assumeRolePolicyDocument, trust or trustTemplateRef must be set"
 type RoleSpec struct {
 	// The trust relationship policy document that grants an entity permission to
 	// assume the role.
@@ -30,8 +31,11 @@ type RoleSpec struct {
 	//     return (\u000D)
 	//
 	// Upon success, the response includes the same trust policy in JSON format.
-	// +kubebuilder:validation:Required
-	AssumeRolePolicyDocument *string `json:"assumeRolePolicyDocument"`
+	//
+	// The document is a Golang template rendered with the values of the cluster, the account
+	// and the ARNs of the roles of the AWSIAMProvision. It is required unless `trust` or `trustTemplateRef` is set.
+	// +optional
+	AssumeRolePolicyDocument *string `json:"assumeRolePolicyDocument,omitempty"`
 	// The name of the role to create.
 	//
 	// IAM user, group, role, and policy names must be unique within the account.
@@ -53,6 +57,45 @@ type RoleSpec struct {
 	// If any one of the tags is invalid or if you exceed the allowed maximum number
 	// of tags, then the entire request fails and the resource is not created.
 	Tags []*Tag `json:"tags,omitempty"`
+	// Trust - the structured trust relationship policy compiled by the operator into the trust relationship
+	// policy document, an alternative to `assumeRolePolicyDocument`.
+	// +optional
+	Trust *RoleTrust `json:"trust,omitempty"`
+	// TrustTemplateRef - the IAMPolicyTemplate rendered into the trust relationship policy document,
+	// an alternative to `assumeRolePolicyDocument`.
+	// +optional
+	TrustTemplateRef *PolicyTemplateReference `json:"trustTemplateRef,omitempty"`
+}
+
+// RoleTrust defines the principals allowed to assume the role.
+// +kubebuilder:validation:XValidation:rule="has(self.serviceAccounts) || has(self.principals)",message="at least one of serviceAccounts or principals must be set"
+type RoleTrust struct {
+	// Audience - the audience of the tokens of the ServiceAccounts, `sts.amazonaws.com` by default.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+	// Conditions - the additional conditions of all the statements of the document.
+	// +optional
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
+	// Principals - the additional principals allowed to assume the role, e.g. AWS services or other roles.
+	// +optional
+	Principals []TrustPrincipal `json:"principals,omitempty"`
+	// ServiceAccounts - the ServiceAccounts of the cluster allowed to assume the role with the web identity
+	// of the OIDC provider of the cluster. The namespace and the name support the `*` and `?` wildcards.
+	// +optional
+	ServiceAccounts []ServiceAccountReference `json:"serviceAccounts,omitempty"`
+}
+
+// TrustPrincipal defines the principal of a statement of the trust relationship policy document.
+type TrustPrincipal struct {
+	// Actions - the actions allowed to the principal, `sts:AssumeRole` by default.
+	// +optional
+	Actions []string `json:"actions,omitempty"`
+	// Identifiers - the ARNs of the AWS principals, the names of the services or the federated identity providers.
+	// +kubebuilder:validation:MinItems=1
+	Identifiers []string `json:"identifiers"`
+	// Type - the type of the principal.
+	// +kubebuilder:validation:Enum=AWS;Service;Federated
+	Type string `json:"type"`
 }
 
 // RoleStatus defines the observed state of Role
@@ -74,13 +117,28 @@ type RoleStatus struct {
 }
 
 type AWSIAMProvisionRole struct {
-	Spec RoleSpec `json:"spec"`
+	// ServiceAccounts - the ServiceAccounts of the workload cluster annotated with the ARN of the role
+	// by `eks.amazonaws.com/role-arn`, the missing ones are created.
+	// +optional
+	ServiceAccounts []ServiceAccountReference `json:"serviceAccounts,omitempty"`
+	Spec            RoleSpec                  `json:"spec"`
+}
+
+// ServiceAccountReference defines the ServiceAccount of the workload cluster.
+type ServiceAccountReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 // AWSIAMProvisionStatusRole defines the observed state of AWSIAMProvision's roles.
 type AWSIAMProvisionStatusRole struct {
-	Name    *string    `json:"name,omitempty"`
-	Message string     `json:"message,omitempty"`
-	Phase   string     `json:"phase,omitempty"`
-	Status  RoleStatus `json:"status,omitempty"`
+	// Conditions - latest available observations of the role state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	Name       *string            `json:"name,omitempty"`
+	Message    string             `json:"message,omitempty"`
+	Phase      string             `json:"phase,omitempty"`
+	Status     RoleStatus         `json:"status,omitempty"`
 }
@@ -0,0 +1,245 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/controller"
+)
+
+// runImportACK generates the AWSIAMProvision equivalent to the ACK Role and Policy CRs
+// and optionally hands the IAM resources over to the operator.
+func runImportACK(args []string) int {
+	var opts controller.ACKImport
+	var filename string
+	var handover bool
+	var output string
+	var selector string
+	flags := flag.NewFlagSet("import-ack", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import-ack --name <name> --eks-cluster-name <cluster> [flags]\n\n"+
+			"Generates the AWSIAMProvision equivalent to the Role and Policy CRs of the ACK iam-controller.\n"+
+			"With --handover the ACK CRs are deleted with the retain deletion policy, the IAM resources are tagged\n"+
+			"to be adopted by the operator and the AWSIAMProvision is created, the IAM resources are not recreated.\n\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.Name, "name", "", "The name of the generated AWSIAMProvision.")
+	flags.StringVar(&opts.Namespace, "n", "default", "The namespace of the ACK CRs and the generated AWSIAMProvision.")
+	flags.StringVar(&opts.EKSClusterName, "eks-cluster-name", "", "The EKS cluster of the generated AWSIAMProvision.")
+	flags.StringVar(&opts.Region, "region", "",
+		"The region of the generated AWSIAMProvision, if not set it is taken from the ACK CRs.")
+	flags.StringVar(&opts.OIDCProviderARN, "oidc-provider-arn", "",
+		"The ARN of the OIDC provider of the cluster which is replaced with the placeholders, "+
+			"if not set it is detected in the trust relationship policy documents.")
+	flags.StringVar(&opts.ClusterSource, "cluster-source", iamv1alpha1.ClusterSourceCAPA,
+		"The cluster source of the generated AWSIAMProvision: CAPA, EKS or OIDC.")
+	flags.StringVar(&selector, "l", "", "The label selector of the ACK CRs, e.g. app=foo.")
+	flags.StringVar(&filename, "f", "",
+		"The manifest of the ACK CRs, if not set they are read from the cluster of the current kubeconfig.")
+	flags.StringVar(&output, "o", "", "The file which the AWSIAMProvision is written to, stdout if not set.")
+	flags.BoolVar(&handover, "handover", false,
+		"Hand the IAM resources over to the operator in the cluster of the current kubeconfig.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 1
+	}
+
+	ctrl.SetLogger(logr.Discard())
+
+	if len(opts.Name) == 0 || len(opts.EKSClusterName) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --name and --eks-cluster-name must be set")
+		flags.Usage()
+
+		return 1
+	}
+
+	if handover && len(filename) > 0 {
+		fmt.Fprintln(os.Stderr, "Error: --handover reads the ACK CRs from the cluster, -f is not supported")
+
+		return 1
+	}
+
+	if err := importACK(context.Background(), opts, filename, selector, output, handover); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+
+		return 1
+	}
+
+	return 0
+}
+
+func importACK(ctx context.Context, opts controller.ACKImport, filename, selector, output string, handover bool) error {
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	var (
+		k8sClient client.Client
+		policies  []ackiamv1alpha1.Policy
+		roles     []ackiamv1alpha1.Role
+	)
+
+	if len(filename) > 0 {
+		if policies, roles, err = decodeACKResources(filename, opts.Namespace, labelSelector); err != nil {
+			return err
+		}
+	} else {
+		cfg, err := ctrl.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		if k8sClient, err = client.New(cfg, client.Options{Scheme: scheme}); err != nil {
+			return err
+		}
+
+		listOptions := []client.ListOption{
+			client.InNamespace(opts.Namespace),
+			client.MatchingLabelsSelector{Selector: labelSelector},
+		}
+
+		policyList := &ackiamv1alpha1.PolicyList{}
+		if err := k8sClient.List(ctx, policyList, listOptions...); err != nil {
+			return err
+		}
+
+		roleList := &ackiamv1alpha1.RoleList{}
+		if err := k8sClient.List(ctx, roleList, listOptions...); err != nil {
+			return err
+		}
+
+		policies, roles = policyList.Items, roleList.Items
+	}
+
+	result, err := controller.ImportACK(opts, policies, roles)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	// The AWSIAMProvision is written before the handover to be kept if the handover fails.
+	if err := writeAWSIAMProvision(output, result.AWSIAMProvision); err != nil {
+		return err
+	}
+
+	if !handover {
+		return nil
+	}
+
+	iamClient, err := aws_sdk.NewIAMClient(ctx, result.AWSIAMProvision.Spec.Region,
+		*result.AWSIAMProvision.Spec.Path, logr.Discard())
+	if err != nil {
+		return err
+	}
+
+	return controller.HandOverACKResources(ctx, k8sClient, iamClient, result, policies, roles)
+}
+
+// decodeACKResources returns the ACK Role and Policy CRs of the manifest in the namespace matching the selector,
+// the CRs without the namespace are considered to be in it.
+func decodeACKResources(filename, namespace string,
+	selector labels.Selector) ([]ackiamv1alpha1.Policy, []ackiamv1alpha1.Role, error) {
+	objects, err := decodeManifest(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matches := func(object client.Object) bool {
+		return (len(object.GetNamespace()) == 0 || object.GetNamespace() == namespace) &&
+			selector.Matches(labels.Set(object.GetLabels()))
+	}
+
+	var (
+		policies []ackiamv1alpha1.Policy
+		roles    []ackiamv1alpha1.Role
+	)
+
+	for _, object := range objects {
+		switch obj := object.(type) {
+		case *ackiamv1alpha1.Policy:
+			if matches(obj) {
+				policies = append(policies, *obj)
+			}
+		case *ackiamv1alpha1.Role:
+			if matches(obj) {
+				roles = append(roles, *obj)
+			}
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	return policies, roles, nil
+}
+
+// writeAWSIAMProvision writes the AWSIAMProvision as the YAML manifest without the empty metadata and status.
+func writeAWSIAMProvision(output string, awsIAMProvision *iamv1alpha1.AWSIAMProvision) error {
+	data, err := yaml.Marshal(struct {
+		APIVersion string                          `json:"apiVersion"`
+		Kind       string                          `json:"kind"`
+		Metadata   map[string]string               `json:"metadata"`
+		Spec       iamv1alpha1.AWSIAMProvisionSpec `json:"spec"`
+	}{
+		APIVersion: awsIAMProvision.APIVersion,
+		Kind:       awsIAMProvision.Kind,
+		Metadata:   map[string]string{"name": awsIAMProvision.Name, "namespace": awsIAMProvision.Namespace},
+		Spec:       awsIAMProvision.Spec,
+	})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(output) > 0 {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
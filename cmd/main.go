@@ -28,16 +28,19 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	cpv1beta2 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
 	"aws-iam-provisioner.operators.infra/internal/controller"
 	// +kubebuilder:scaffold:imports
 )
@@ -52,31 +55,121 @@ func init() {
 	utilruntime.Must(iamv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(iamctrlv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(cpv1beta2.AddToScheme(scheme))
+	utilruntime.Must(clusterv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		os.Exit(runPlan(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(runRender(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import-ack" {
+		os.Exit(runImportACK(os.Args[2:]))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
+	var secureMetrics bool
 	var probeAddr string
+	var iamPathPrefix string
+	var iamNameTemplate string
+	var maxConcurrentReconciles int
+	var iamWorkers int
+	var iamAPIQPS float64
+	var iamAPIBurst int
+	var mode string
+	var backend string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
+	flag.BoolVar(&secureMetrics, "metrics-secure", false,
+		"If set, the metrics endpoint is served securely via HTTPS with authentication and authorization. "+
+			"By default the metrics endpoint is served via HTTP.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&iamPathPrefix, "iam-path-prefix", aws_sdk.DefaultPathPrefix,
+		"The IAM path of the provisioned roles and policies, e.g. /staging/. "+
+			"Use different paths to isolate several installations of the operator in one AWS account.")
+	flag.StringVar(&iamNameTemplate, "iam-name-template", "",
+		"The Golang template of the IAM role and policy names, e.g. {{ .ClusterName }}-{{ .Name }}. "+
+			"Leave empty to use the names from the AWSIAMProvision spec as is.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of AWSIAMProvision resources reconciled in parallel.")
+	flag.IntVar(&iamWorkers, "iam-workers", 4,
+		"The number of workers syncing the roles and policies of an AWSIAMProvision resource in parallel.")
+	flag.Float64Var(&iamAPIQPS, "iam-api-qps", aws_sdk.DefaultRateLimitQPS,
+		"The maximum number of AWS IAM API calls per second for an AWS account shared by all the reconciliations.")
+	flag.IntVar(&iamAPIBurst, "iam-api-burst", aws_sdk.DefaultRateLimitBurst,
+		"The maximum burst of AWS IAM API calls for an AWS account.")
+	flag.StringVar(&mode, "mode", iamv1alpha1.ModeEnforce,
+		"The mode of the reconciliation: Enforce applies the changes to AWS IAM, "+
+			"Observe only publishes them in the status of the AWSIAMProvision. Can be overridden by spec.mode.")
+	flag.StringVar(&backend, "backend", iamv1alpha1.BackendIAM,
+		"The backend of the roles and policies: IAM calls the AWS IAM API, ACK emits the Role and Policy resources "+
+			"of the ACK iam-controller and watches them. Can be overridden by spec.backend.")
 	opts := zap.Options{Development: true, StacktraceLevel: zapcore.DPanicLevel}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if !strings.HasPrefix(iamPathPrefix, "/") || !strings.HasSuffix(iamPathPrefix, "/") {
+		setupLog.Error(fmt.Errorf("path must begin and end with a forward slash: %s", iamPathPrefix),
+			"invalid IAM path prefix")
+		os.Exit(1)
+	}
+
+	if maxConcurrentReconciles < 1 {
+		setupLog.Error(fmt.Errorf("must be greater than 0: %d", maxConcurrentReconciles),
+			"invalid max concurrent reconciles")
+		os.Exit(1)
+	}
+
+	if iamWorkers < 1 {
+		setupLog.Error(fmt.Errorf("must be greater than 0: %d", iamWorkers), "invalid IAM workers")
+		os.Exit(1)
+	}
+
+	if iamAPIQPS <= 0 || iamAPIBurst < 1 {
+		setupLog.Error(fmt.Errorf("must be greater than 0: qps %v, burst %d", iamAPIQPS, iamAPIBurst),
+			"invalid IAM API rate limit")
+		os.Exit(1)
+	}
+
+	aws_sdk.SetRateLimit(iamAPIQPS, iamAPIBurst)
+
+	if mode != iamv1alpha1.ModeEnforce && mode != iamv1alpha1.ModeObserve {
+		setupLog.Error(fmt.Errorf("must be %s or %s: %s", iamv1alpha1.ModeEnforce, iamv1alpha1.ModeObserve, mode),
+			"invalid mode")
+		os.Exit(1)
+	}
+
+	if backend != iamv1alpha1.BackendIAM && backend != iamv1alpha1.BackendACK {
+		setupLog.Error(fmt.Errorf("must be %s or %s: %s", iamv1alpha1.BackendIAM, iamv1alpha1.BackendACK, backend),
+			"invalid backend")
+		os.Exit(1)
+	}
+
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
 	// More info:
 	// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.1/pkg/metrics/server
 	// - https://book.kubebuilder.io/reference/metrics.html
 	metricsServerOptions := metricsserver.Options{
-		BindAddress: metricsAddr,
+		BindAddress:   metricsAddr,
+		SecureServing: secureMetrics,
+	}
+
+	if secureMetrics {
+		// FilterProvider is used to protect the metrics endpoint with authn/authz.
+		// These configurations ensure that only authorized users and service accounts
+		// can access the metrics endpoint. The RBAC are configured in 'config/rbac/kustomization.yaml'.
+		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
 	options := ctrl.Options{
@@ -127,11 +220,38 @@ func main() {
 	}
 
 	if err = (&controller.AWSIAMProvisionReconciler{
-		ReconciliationManager: &controller.ReconciliationManager{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+		APIReader:               mgr.GetAPIReader(),
+		Backend:                 backend,
+		Client:                  mgr.GetClient(),
+		IAMNameTemplate:         iamNameTemplate,
+		IAMPathPrefix:           iamPathPrefix,
+		IAMWorkers:              iamWorkers,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Mode:                    mode,
+		NewIAMClient:            controller.NewIAMClient,
+		NewWorkloadClient:       controller.NewWorkloadClient,
+		Recorder:                mgr.GetEventRecorderFor("aws-iam-provisioner"),
+		Scheme:                  mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AWSIAMProvision")
 		os.Exit(1)
 	}
+	if err = (&controller.AWSIAMProvisionFleetReconciler{
+		Client:          mgr.GetClient(),
+		IAMNameTemplate: iamNameTemplate,
+		Scheme:          mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWSIAMProvisionFleet")
+		os.Exit(1)
+	}
+	if err = (&controller.AWSIAMProvisionTemplateReconciler{
+		Client:          mgr.GetClient(),
+		IAMNameTemplate: iamNameTemplate,
+		Scheme:          mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWSIAMProvisionTemplate")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
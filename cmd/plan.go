@@ -0,0 +1,356 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	cpv1beta2 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/controller"
+)
+
+// planExitCodeChanges - exit code of the plan subcommand with --detailed-exitcode when there are changes.
+const planExitCodeChanges = 2
+
+// runPlan prints the changes which the operator would apply to AWS IAM for the AWSIAMProvision manifest.
+func runPlan(args []string) int {
+	var controlPlaneFile string
+	var detailedExitCode bool
+	var filename string
+	var iamNameTemplate string
+	var iamPathPrefix string
+	var verbose bool
+	flags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s plan -f <manifest> [flags]\n\n"+
+			"Prints the changes of AWS IAM resources which the operator would apply for the AWSIAMProvision manifest.\n"+
+			"AWS credentials are taken from the default credential chain, AWS IAM is only read.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&filename, "f", "",
+		"The manifest of the AWSIAMProvision, it can contain the AWSManagedControlPlane as well.")
+	flags.StringVar(&controlPlaneFile, "control-plane", "",
+		"The manifest of the AWSManagedControlPlane. "+
+			"If not set and not found in the AWSIAMProvision manifest, it is read from the cluster of the current kubeconfig.")
+	flags.BoolVar(&detailedExitCode, "detailed-exitcode", false,
+		"Return exit code 0 when there are no changes, 1 on error and 2 when there are changes.")
+	flags.StringVar(&iamPathPrefix, "iam-path-prefix", aws_sdk.DefaultPathPrefix,
+		"The IAM path of the provisioned roles and policies, the same as of the operator.")
+	flags.StringVar(&iamNameTemplate, "iam-name-template", "",
+		"The Golang template of the IAM role and policy names, the same as of the operator.")
+	flags.BoolVar(&verbose, "v", false, "Print the logs of the reconciliation to stderr.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 1
+	}
+
+	logger := logr.Discard()
+	if verbose {
+		logger = zap.New(zap.WriteTo(os.Stderr), zap.UseDevMode(true))
+	}
+
+	ctrl.SetLogger(logger)
+
+	if len(filename) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: the AWSIAMProvision manifest must be set by -f")
+		flags.Usage()
+
+		return 1
+	}
+
+	plan, err := computePlan(ctrl.LoggerInto(context.Background(), logger),
+		filename, controlPlaneFile, iamPathPrefix, iamNameTemplate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+
+		return 1
+	}
+
+	printPlan(os.Stdout, plan)
+
+	if detailedExitCode && len(plan.Actions) > 0 {
+		return planExitCodeChanges
+	}
+
+	return 0
+}
+
+func computePlan(ctx context.Context, filename, controlPlaneFile, iamPathPrefix, iamNameTemplate string) (*controller.Plan, error) {
+	var (
+		awsIAMProvision *iamv1alpha1.AWSIAMProvision
+		eksCP           *cpv1beta2.AWSManagedControlPlane
+	)
+
+	for _, file := range []string{filename, controlPlaneFile} {
+		if len(file) == 0 {
+			continue
+		}
+
+		objects, err := decodeManifest(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			switch obj := object.(type) {
+			case *iamv1alpha1.AWSIAMProvision:
+				if awsIAMProvision != nil {
+					return nil, fmt.Errorf("several AWSIAMProvision found, only one is supported")
+				}
+
+				awsIAMProvision = obj
+			case *cpv1beta2.AWSManagedControlPlane:
+				eksCP = obj
+			}
+		}
+	}
+
+	if awsIAMProvision == nil {
+		return nil, fmt.Errorf("AWSIAMProvision not found in %s", filename)
+	}
+
+	if len(awsIAMProvision.Namespace) == 0 {
+		awsIAMProvision.Namespace = "default"
+	}
+
+	// The clusters of other sources are resolved by the reconciler.
+	capaSource := awsIAMProvision.Spec.ClusterSource == nil ||
+		awsIAMProvision.Spec.ClusterSource.Type == iamv1alpha1.ClusterSourceCAPA
+	if !capaSource {
+		eksCP = nil
+	}
+
+	var k8sClient client.Client
+	if (capaSource && eksCP == nil) || len(awsIAMProvision.UID) == 0 || len(awsIAMProvision.Spec.VariablesFrom) > 0 ||
+		hasPolicyTemplateRefs(awsIAMProvision) {
+		cfg, err := ctrl.GetConfig()
+		if err != nil && capaSource && eksCP == nil {
+			return nil, fmt.Errorf("AWSManagedControlPlane not found in the manifests and the cluster is not available: %w", err)
+		}
+
+		if err == nil {
+			if k8sClient, err = client.New(cfg, client.Options{Scheme: scheme}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if capaSource && eksCP == nil {
+		eksCP = &cpv1beta2.AWSManagedControlPlane{}
+		key := client.ObjectKey{Name: awsIAMProvision.Spec.EKSClusterName, Namespace: awsIAMProvision.Namespace}
+		if err := k8sClient.Get(ctx, key, eksCP); err != nil {
+			return nil, fmt.Errorf("unable to get AWSManagedControlPlane %s: %w", key, err)
+		}
+	}
+
+	// The ownership of the IAM resources is identified by the UID of the CR deployed to the cluster.
+	if len(awsIAMProvision.UID) == 0 && k8sClient != nil {
+		deployed := &iamv1alpha1.AWSIAMProvision{}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(awsIAMProvision), deployed); err == nil {
+			awsIAMProvision.UID = deployed.UID
+		}
+	}
+
+	r := &controller.AWSIAMProvisionReconciler{
+		Client:          k8sClient,
+		IAMNameTemplate: iamNameTemplate,
+		IAMPathPrefix:   iamPathPrefix,
+		IAMWorkers:      4,
+		NewIAMClient:    controller.NewIAMClient,
+		Scheme:          scheme,
+	}
+
+	return r.Plan(ctx, awsIAMProvision, eksCP)
+}
+
+// hasPolicyTemplateRefs reports whether the CR references IAMPolicyTemplates, they are read from the cluster.
+func hasPolicyTemplateRefs(awsIAMProvision *iamv1alpha1.AWSIAMProvision) bool {
+	for _, policy := range awsIAMProvision.Spec.Policies {
+		if policy.Spec.TemplateRef != nil {
+			return true
+		}
+	}
+
+	for _, role := range awsIAMProvision.Spec.Roles {
+		if role.Spec.TrustTemplateRef != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeManifest decodes all the objects of the multi-document YAML or JSON manifest known to the scheme.
+func decodeManifest(filename string) ([]interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	var objects []interface{}
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", filename, err)
+		}
+
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		object, _, err := decoder.Decode(document, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", filename, err)
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+func printPlan(w io.Writer, plan *controller.Plan) {
+	counts := make(map[string]int)
+	for _, action := range plan.Actions {
+		counts[action.Operation]++
+
+		switch action.Operation {
+		case aws_sdk.ActionCreate:
+			fmt.Fprintf(w, "  + %s %s%s will be created\n", strings.ToLower(action.ResourceType), plan.PathPrefix, action.Name)
+			printDocument(w, "      + ", action.Document)
+		case aws_sdk.ActionUpdate:
+			fmt.Fprintf(w, "  ~ %s %s%s document will be updated\n", strings.ToLower(action.ResourceType), plan.PathPrefix, action.Name)
+			printDocumentDiff(w, action.PreviousDocument, action.Document)
+		case aws_sdk.ActionTag:
+			fmt.Fprintf(w, "  ~ %s %s%s will be tagged\n", strings.ToLower(action.ResourceType), plan.PathPrefix, action.Name)
+			for _, tag := range action.Tags {
+				fmt.Fprintf(w, "      + %s = %s\n", *tag.Key, *tag.Value)
+			}
+		case aws_sdk.ActionAttach:
+			fmt.Fprintf(w, "  + policy %s will be attached to role %s\n", action.Name, action.RoleName)
+		case aws_sdk.ActionDetach:
+			fmt.Fprintf(w, "  - policy %s will be detached from role %s\n", action.Name, action.RoleName)
+		case aws_sdk.ActionDelete:
+			fmt.Fprintf(w, "  - %s %s%s will be deleted\n", strings.ToLower(action.ResourceType), plan.PathPrefix, action.Name)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	for _, role := range plan.ConflictRoles {
+		fmt.Fprintf(w, "  ! role %s is owned by another AWSIAMProvision and will be skipped\n\n", role)
+	}
+
+	for _, policy := range plan.ConflictPolicies {
+		fmt.Fprintf(w, "  ! policy %s is owned by another AWSIAMProvision and will be skipped\n\n", policy)
+	}
+
+	if len(plan.Actions) == 0 {
+		fmt.Fprintln(w, "No changes. AWS IAM resources match the AWSIAMProvision.")
+
+		return
+	}
+
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to tag, %d to attach, %d to detach, %d to delete.\n",
+		counts[aws_sdk.ActionCreate], counts[aws_sdk.ActionUpdate], counts[aws_sdk.ActionTag],
+		counts[aws_sdk.ActionAttach], counts[aws_sdk.ActionDetach], counts[aws_sdk.ActionDelete])
+}
+
+// formatDocument decodes and indents the JSON policy document, the document is returned as is if it is not JSON.
+func formatDocument(document string) []string {
+	if unescaped, err := url.PathUnescape(document); err == nil {
+		document = unescaped
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(strings.TrimSpace(document)), "", "  "); err == nil {
+		document = indented.String()
+	}
+
+	return strings.Split(strings.TrimSpace(document), "\n")
+}
+
+func printDocument(w io.Writer, prefix, document string) {
+	for _, line := range formatDocument(document) {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+// printDocumentDiff prints the line diff of the documents based on the longest common subsequence.
+func printDocumentDiff(w io.Writer, previousDocument, document string) {
+	a, b := formatDocument(previousDocument), formatDocument(document)
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(w, "        %s\n", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(w, "      + %s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(w, "      - %s\n", a[i])
+			i++
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/controller"
+)
+
+// runRender renders the documents of all the roles and policies of the AWSIAMProvision manifest offline.
+func runRender(args []string) int {
+	var filename string
+	var iamNameTemplate string
+	var iamPathPrefix string
+	var outputDir string
+	var valuesFile string
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s render -f <manifest> [flags]\n\n"+
+			"Renders and validates the documents of all the roles and policies of the AWSIAMProvision manifest.\n"+
+			"Neither the cluster nor AWS is accessed, the values which are not supplied are faked.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&filename, "f", "", "The manifest of the AWSIAMProvision.")
+	flags.StringVar(&valuesFile, "values", "",
+		"The YAML or JSON file with the values of the environment: accountID, oidcProviderARN and region.")
+	flags.StringVar(&outputDir, "output-dir", "",
+		"The directory which the documents are written to as <roles|policies>/<name>.json, "+
+			"if not set the documents are printed to stdout.")
+	flags.StringVar(&iamNameTemplate, "iam-name-template", "",
+		"The Golang template of the IAM role and policy names, the same as of the operator.")
+	flags.StringVar(&iamPathPrefix, "iam-path-prefix", aws_sdk.DefaultPathPrefix,
+		"The IAM path of the roles of the RoleARNs template values, the same as of the operator.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 1
+	}
+
+	if len(filename) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: the AWSIAMProvision manifest must be set by -f")
+		flags.Usage()
+
+		return 1
+	}
+
+	documents, err := renderDocuments(filename, valuesFile, iamPathPrefix, iamNameTemplate)
+	if err == nil {
+		if len(outputDir) > 0 {
+			err = writeDocuments(outputDir, documents)
+		} else {
+			printDocuments(os.Stdout, documents)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+
+		return 1
+	}
+
+	return 0
+}
+
+func renderDocuments(filename, valuesFile, iamPathPrefix, iamNameTemplate string) ([]controller.RenderedDocument, error) {
+	objects, err := decodeManifest(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		awsIAMProvisions []*iamv1alpha1.AWSIAMProvision
+		policyTemplates  []iamv1alpha1.IAMPolicyTemplate
+	)
+
+	for _, object := range objects {
+		switch obj := object.(type) {
+		case *iamv1alpha1.AWSIAMProvision:
+			awsIAMProvisions = append(awsIAMProvisions, obj)
+		case *iamv1alpha1.IAMPolicyTemplate:
+			policyTemplates = append(policyTemplates, *obj)
+		}
+	}
+
+	if len(awsIAMProvisions) != 1 {
+		return nil, fmt.Errorf("exactly one AWSIAMProvision is expected in %s, found %d", filename, len(awsIAMProvisions))
+	}
+
+	awsIAMProvision := awsIAMProvisions[0]
+	if len(awsIAMProvision.Namespace) == 0 {
+		awsIAMProvision.Namespace = "default"
+	}
+
+	var values controller.RenderValues
+	if len(valuesFile) > 0 {
+		data, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.UnmarshalStrict(data, &values); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", valuesFile, err)
+		}
+	}
+
+	values.PolicyTemplates = policyTemplates
+
+	r := &controller.AWSIAMProvisionReconciler{
+		IAMNameTemplate: iamNameTemplate,
+		IAMPathPrefix:   iamPathPrefix,
+		Scheme:          scheme,
+	}
+
+	return r.Render(logr.NewContext(context.Background(), logr.Discard()), awsIAMProvision, values)
+}
+
+func documentPath(document controller.RenderedDocument) string {
+	directory := "policies"
+	if document.ResourceType == aws_sdk.ResourceTypeRole {
+		directory = "roles"
+	}
+
+	return filepath.Join(directory, document.Name+".json")
+}
+
+func writeDocuments(outputDir string, documents []controller.RenderedDocument) error {
+	for _, document := range documents {
+		path := filepath.Join(outputDir, documentPath(document))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		content := strings.Join(formatDocument(document.Document), "\n") + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printDocuments(w io.Writer, documents []controller.RenderedDocument) {
+	for i, document := range documents {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "# %s %s (%s)\n", strings.ToLower(document.ResourceType), document.Name, documentPath(document))
+		printDocument(w, "", document.Document)
+	}
+}
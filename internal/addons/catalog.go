@@ -0,0 +1,132 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package addons contains the catalog of the role and policy bundles of common EKS add-ons embedded into the operator.
+// The bundles are stored as catalog/<name>/<version>.yaml, the versions are immutable, the changed permissions
+// of an add-on are released as its new version.
+package addons
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+//go:embed catalog
+var catalogFS embed.FS
+
+var versionPattern = regexp.MustCompile(`^v([1-9][0-9]*)$`)
+
+// Bundle - the role and policy of an add-on. The role is assumed by the ServiceAccount of the add-on,
+// the policy is the parameterized document the same as the one of IAMPolicyTemplate.
+type Bundle struct {
+	Name    string `json:"-"`
+	Version string `json:"-"`
+
+	Description string `json:"description"`
+	// ServiceAccount - the `<namespace>/<name>` of the ServiceAccount of the default installation of the add-on.
+	ServiceAccount string                            `json:"serviceAccount"`
+	Template       iamv1alpha1.IAMPolicyTemplateSpec `json:"template"`
+}
+
+// catalog - the bundles by the names of the add-ons sorted by the versions in ascending order.
+var catalog = mustLoadCatalog()
+
+func mustLoadCatalog() map[string][]*Bundle {
+	bundles := make(map[string][]*Bundle)
+	err := fs.WalkDir(catalogFS, "catalog", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		version := strings.TrimSuffix(path.Base(file), ".yaml")
+		if !versionPattern.MatchString(version) || path.Ext(file) != ".yaml" {
+			return fmt.Errorf("unexpected file %s of the catalog", file)
+		}
+
+		data, err := catalogFS.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		bundle := &Bundle{Name: path.Base(path.Dir(file)), Version: version}
+		if err := yaml.UnmarshalStrict(data, bundle); err != nil {
+			return fmt.Errorf("unable to decode %s: %w", file, err)
+		}
+
+		bundles[bundle.Name] = append(bundles[bundle.Name], bundle)
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, versions := range bundles {
+		sort.Slice(versions, func(i, j int) bool {
+			return versionNumber(versions[i].Version) < versionNumber(versions[j].Version)
+		})
+	}
+
+	return bundles
+}
+
+func versionNumber(version string) int {
+	number, _ := strconv.Atoi(versionPattern.FindStringSubmatch(version)[1])
+
+	return number
+}
+
+// Names returns the sorted names of the add-ons of the catalog.
+func Names() []string {
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Get returns the bundle of the add-on of the version, the latest version if it is empty.
+func Get(name, version string) (*Bundle, error) {
+	versions, ok := catalog[name]
+	if !ok {
+		return nil, fmt.Errorf("add-on %s is not in the catalog, available: %s", name, strings.Join(Names(), ", "))
+	}
+
+	if len(version) == 0 {
+		return versions[len(versions)-1], nil
+	}
+
+	for _, bundle := range versions {
+		if bundle.Version == version {
+			return bundle, nil
+		}
+	}
+
+	return nil, fmt.Errorf("version %s of add-on %s is not in the catalog", version, name)
+}
@@ -0,0 +1,680 @@
+package aws_sdk
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// Operations of the actions recorded by DryRunIAMManager.
+const (
+	ActionAttach = "Attach"
+	ActionCreate = "Create"
+	ActionDelete = "Delete"
+	ActionDetach = "Detach"
+	ActionTag    = "Tag"
+	ActionUpdate = "Update"
+)
+
+// Resource types of the actions recorded by DryRunIAMManager.
+const (
+	ResourceTypePolicy = "Policy"
+	ResourceTypeRole   = "Role"
+)
+
+// actionOrder - the order in which the actions of a plan are listed.
+var actionOrder = map[string]int{
+	ActionDetach: 0,
+	ActionDelete: 1,
+	ActionTag:    2,
+	ActionCreate: 3,
+	ActionUpdate: 4,
+	ActionAttach: 5,
+}
+
+// Action - intended mutation of an AWS IAM resource.
+type Action struct {
+	// Document - the policy document or the trust relationship policy document of the create and update operations.
+	Document string
+	Name     string
+	// Operation - one of the Action* constants.
+	Operation string
+	// PreviousDocument - the remote document of the update and delete operations.
+	PreviousDocument string
+	// ResourceType - one of the ResourceType* constants.
+	ResourceType string
+	// RoleName - the role which the policy is attached to or detached from.
+	RoleName string
+	// Tags - the tags of the create and tag operations.
+	Tags []iamType.Tag
+}
+
+func (a Action) String() string {
+	if len(a.RoleName) > 0 {
+		return fmt.Sprintf("%s %s %s, role %s", a.Operation, a.ResourceType, a.Name, a.RoleName)
+	}
+
+	return fmt.Sprintf("%s %s %s", a.Operation, a.ResourceType, a.Name)
+}
+
+// DryRunIAMManager is a read-only IAMManager, the reads are delegated to the wrapped IAMManager
+// and the mutations are recorded as actions instead of being performed.
+// The recorded mutations are reflected by the subsequent reads, so the wrapped IAMManager sees the intended state.
+type DryRunIAMManager struct {
+	actions []Action
+	// attached - the attachments changed by the actions: role name -> policy name -> attached or detached.
+	attached map[string]map[string]bool
+	// createdPolicies, createdRoles - the resources which do not exist remotely.
+	createdPolicies map[string]struct{}
+	createdRoles    map[string]struct{}
+	// documents - the documents of the created policies.
+	documents map[string]string
+	manager   IAMManager
+	mu        sync.Mutex
+	// policies, roles - the resources changed by the actions, nil value means the resource was deleted.
+	policies map[string]*iamType.Policy
+	roles    map[string]*iamType.Role
+}
+
+var _ IAMManager = &DryRunIAMManager{}
+
+func NewDryRunIAMManager(manager IAMManager) *DryRunIAMManager {
+	return &DryRunIAMManager{
+		attached:        make(map[string]map[string]bool),
+		createdPolicies: make(map[string]struct{}),
+		createdRoles:    make(map[string]struct{}),
+		documents:       make(map[string]string),
+		manager:         manager,
+		policies:        make(map[string]*iamType.Policy),
+		roles:           make(map[string]*iamType.Role),
+	}
+}
+
+// Actions returns the recorded actions in the order of application by type, then by resource name.
+// A policy document is updated by recreation of the policy, so the recreation is presented as a single update.
+func (m *DryRunIAMManager) Actions() []Action {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make(map[string]string)
+	recreated := make(map[string]struct{})
+	for _, action := range m.actions {
+		if action.ResourceType != ResourceTypePolicy {
+			continue
+		}
+
+		switch action.Operation {
+		case ActionDelete:
+			deleted[action.Name] = action.PreviousDocument
+		case ActionCreate:
+			if _, ok := deleted[action.Name]; ok {
+				recreated[action.Name] = struct{}{}
+			}
+		}
+	}
+
+	var (
+		actions  []Action
+		detached = make(map[string]struct{})
+	)
+
+	for _, action := range m.actions {
+		if _, ok := recreated[action.Name]; ok && action.ResourceType == ResourceTypePolicy {
+			switch action.Operation {
+			case ActionDelete:
+				continue
+			case ActionDetach:
+				detached[action.RoleName+"/"+action.Name] = struct{}{}
+				continue
+			case ActionAttach:
+				// The policy is re-attached to the roles it was detached from before the recreation.
+				if _, ok := detached[action.RoleName+"/"+action.Name]; ok {
+					delete(detached, action.RoleName+"/"+action.Name)
+					continue
+				}
+			case ActionCreate:
+				action.Operation = ActionUpdate
+				action.PreviousDocument = deleted[action.Name]
+			}
+		}
+
+		actions = append(actions, action)
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool {
+		if actionOrder[actions[i].Operation] != actionOrder[actions[j].Operation] {
+			return actionOrder[actions[i].Operation] < actionOrder[actions[j].Operation]
+		}
+
+		if actions[i].ResourceType != actions[j].ResourceType {
+			return actions[i].ResourceType < actions[j].ResourceType
+		}
+
+		if actions[i].Name != actions[j].Name {
+			return actions[i].Name < actions[j].Name
+		}
+
+		return actions[i].RoleName < actions[j].RoleName
+	})
+
+	return actions
+}
+
+func (m *DryRunIAMManager) record(action Action) {
+	m.actions = append(m.actions, action)
+}
+
+func (m *DryRunIAMManager) setAttached(policyName, roleName string, attached bool) {
+	if _, ok := m.attached[roleName]; !ok {
+		m.attached[roleName] = make(map[string]bool)
+	}
+
+	m.attached[roleName][policyName] = attached
+}
+
+func (m *DryRunIAMManager) AttachRolePolicy(policyName, roleName *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{
+		Name:         aws.ToString(policyName),
+		Operation:    ActionAttach,
+		ResourceType: ResourceTypePolicy,
+		RoleName:     aws.ToString(roleName),
+	})
+	m.setAttached(aws.ToString(policyName), aws.ToString(roleName), true)
+
+	return nil
+}
+
+func (m *DryRunIAMManager) BatchAttachDetachRolePolicies(proc string, policies []iamType.Policy, roleName *string) error {
+	for _, policy := range policies {
+		switch proc {
+		case ButchAttachProc:
+			if err := m.AttachRolePolicy(policy.PolicyName, roleName); err != nil {
+				return err
+			}
+		case ButchDetachProc:
+			if err := m.DetachRolePolicy(policy.PolicyName, roleName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *DryRunIAMManager) BatchDeletePolicies(policies []iamType.Policy) error {
+	for _, policy := range policies {
+		if err := m.DeletePolicy(policy.PolicyName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *DryRunIAMManager) CreatePolicy(policyName, policyData, _ *string, tags []iamType.Tag) (*iamType.Policy, error) {
+	metadata := m.manager.GetIAMClientMetadata()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{
+		Document:     aws.ToString(policyData),
+		Name:         aws.ToString(policyName),
+		Operation:    ActionCreate,
+		ResourceType: ResourceTypePolicy,
+		Tags:         tags,
+	})
+
+	policy := &iamType.Policy{
+		Arn:        aws.String(metadata.PolicyARN(*policyName)),
+		Path:       aws.String(metadata.PathPrefix),
+		PolicyName: policyName,
+		Tags:       tags,
+	}
+	m.policies[*policyName] = policy
+	m.createdPolicies[*policyName] = struct{}{}
+	m.documents[*policyName] = aws.ToString(policyData)
+
+	result := *policy
+
+	return &result, nil
+}
+
+func (m *DryRunIAMManager) CreateRole(roleName, assumeRolePolicyDocument, _ *string, tags []iamType.Tag) (*iamType.Role, error) {
+	metadata := m.manager.GetIAMClientMetadata()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{
+		Document:     aws.ToString(assumeRolePolicyDocument),
+		Name:         aws.ToString(roleName),
+		Operation:    ActionCreate,
+		ResourceType: ResourceTypeRole,
+		Tags:         tags,
+	})
+
+	role := &iamType.Role{
+		Arn:                      aws.String(metadata.RoleARN(*roleName)),
+		AssumeRolePolicyDocument: assumeRolePolicyDocument,
+		Path:                     aws.String(metadata.PathPrefix),
+		RoleName:                 roleName,
+		Tags:                     tags,
+	}
+	m.roles[*roleName] = role
+	m.createdRoles[*roleName] = struct{}{}
+
+	result := *role
+
+	return &result, nil
+}
+
+func (m *DryRunIAMManager) DeletePolicy(policyName *string) error {
+	// The document is kept to present the recreation of the policy as an update with the document diff.
+	var previousDocument string
+	policy, exists, err := m.GetPolicyByName(policyName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		document, err := m.GetPolicyDocument(policy)
+		if err != nil {
+			return err
+		}
+
+		previousDocument = aws.ToString(document)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{
+		Name:             aws.ToString(policyName),
+		Operation:        ActionDelete,
+		PreviousDocument: previousDocument,
+		ResourceType:     ResourceTypePolicy,
+	})
+	m.policies[*policyName] = nil
+	delete(m.createdPolicies, *policyName)
+	delete(m.documents, *policyName)
+
+	return nil
+}
+
+func (m *DryRunIAMManager) DeleteRole(roleName *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{Name: aws.ToString(roleName), Operation: ActionDelete, ResourceType: ResourceTypeRole})
+	m.roles[*roleName] = nil
+	delete(m.createdRoles, *roleName)
+	delete(m.attached, *roleName)
+
+	return nil
+}
+
+func (m *DryRunIAMManager) DetachRolePolicy(policyName, roleName *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{
+		Name:         aws.ToString(policyName),
+		Operation:    ActionDetach,
+		ResourceType: ResourceTypePolicy,
+		RoleName:     aws.ToString(roleName),
+	})
+	m.setAttached(aws.ToString(policyName), aws.ToString(roleName), false)
+
+	return nil
+}
+
+func (m *DryRunIAMManager) DiffRoleByPolicyDocument(rolePolicyDocumentA, rolePolicyDocumentB *string) (bool, error) {
+	return m.manager.DiffRoleByPolicyDocument(rolePolicyDocumentA, rolePolicyDocumentB)
+}
+
+func (m *DryRunIAMManager) GetIAMClientMetadata() *IAMClientMetadata {
+	return m.manager.GetIAMClientMetadata()
+}
+
+func (m *DryRunIAMManager) GetPolicyByName(policyName *string) (*iamType.Policy, bool, error) {
+	m.mu.Lock()
+	policy, changed := m.policies[*policyName]
+	m.mu.Unlock()
+
+	if !changed {
+		return m.manager.GetPolicyByName(policyName)
+	}
+
+	if policy == nil {
+		return nil, false, nil
+	}
+
+	result := *policy
+
+	return &result, true, nil
+}
+
+func (m *DryRunIAMManager) GetPolicyDocument(policy *iamType.Policy) (*string, error) {
+	m.mu.Lock()
+	document, created := m.documents[*policy.PolicyName]
+	m.mu.Unlock()
+
+	if created {
+		return &document, nil
+	}
+
+	return m.manager.GetPolicyDocument(policy)
+}
+
+func (m *DryRunIAMManager) GetRoleByName(roleName *string) (*iamType.Role, bool, error) {
+	m.mu.Lock()
+	role, changed := m.roles[*roleName]
+	m.mu.Unlock()
+
+	if !changed {
+		return m.manager.GetRoleByName(roleName)
+	}
+
+	if role == nil {
+		return nil, false, nil
+	}
+
+	result := *role
+
+	return &result, true, nil
+}
+
+func (m *DryRunIAMManager) ListAttachedRolePolicies(roleName *string) ([]iamType.Policy, error) {
+	m.mu.Lock()
+	_, created := m.createdRoles[*roleName]
+	m.mu.Unlock()
+
+	var (
+		err      error
+		policies []iamType.Policy
+	)
+
+	if !created {
+		if policies, err = m.manager.ListAttachedRolePolicies(roleName); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	attached := make(map[string]bool)
+	for policyName, isAttached := range m.attached[*roleName] {
+		attached[policyName] = isAttached
+	}
+	m.mu.Unlock()
+
+	var result []iamType.Policy
+	for _, policy := range policies {
+		if isAttached, ok := attached[*policy.PolicyName]; ok {
+			delete(attached, *policy.PolicyName)
+			if !isAttached {
+				continue
+			}
+		}
+
+		current, exists, err := m.GetPolicyByName(policy.PolicyName)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			result = append(result, *current)
+		}
+	}
+
+	for _, policyName := range sortedNames(attached) {
+		if !attached[policyName] {
+			continue
+		}
+
+		policy, exists, err := m.GetPolicyByName(aws.String(policyName))
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			result = append(result, *policy)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *DryRunIAMManager) ListEntitiesForPolicy(policy *iamType.Policy) ([]iamType.PolicyRole, error) {
+	m.mu.Lock()
+	_, created := m.createdPolicies[*policy.PolicyName]
+	m.mu.Unlock()
+
+	var (
+		entities []iamType.PolicyRole
+		err      error
+	)
+
+	if !created {
+		if entities, err = m.manager.ListEntitiesForPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attached := make(map[string]bool)
+	for roleName, policies := range m.attached {
+		if isAttached, ok := policies[*policy.PolicyName]; ok {
+			attached[roleName] = isAttached
+		}
+	}
+
+	var result []iamType.PolicyRole
+	for _, entity := range entities {
+		roleName := aws.ToString(entity.RoleName)
+		if isAttached, ok := attached[roleName]; ok {
+			delete(attached, roleName)
+			if !isAttached {
+				continue
+			}
+		}
+
+		if role, changed := m.roles[roleName]; changed && role == nil {
+			continue
+		}
+
+		result = append(result, entity)
+	}
+
+	for _, roleName := range sortedNames(attached) {
+		if attached[roleName] {
+			result = append(result, iamType.PolicyRole{RoleName: aws.String(roleName)})
+		}
+	}
+
+	return result, nil
+}
+
+func (m *DryRunIAMManager) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, error) {
+	policies, err := m.manager.ListPoliciesByTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	listed := make(map[string]struct{})
+	var result []iamType.Policy
+	for _, policy := range policies {
+		listed[*policy.PolicyName] = struct{}{}
+		if current, changed := m.policies[*policy.PolicyName]; changed {
+			if current != nil && compareTags(getSimilarTags(tags, current.Tags), tags) {
+				result = append(result, *current)
+			}
+
+			continue
+		}
+
+		result = append(result, policy)
+	}
+
+	for _, policyName := range sortedNames(m.policies) {
+		current := m.policies[policyName]
+		if _, ok := listed[policyName]; ok || current == nil {
+			continue
+		}
+
+		if compareTags(getSimilarTags(tags, current.Tags), tags) {
+			result = append(result, *current)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *DryRunIAMManager) ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error) {
+	roles, err := m.manager.ListRolesByTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	listed := make(map[string]struct{})
+	var result []iamType.Role
+	for _, role := range roles {
+		listed[*role.RoleName] = struct{}{}
+		if current, changed := m.roles[*role.RoleName]; changed {
+			if current != nil && compareTags(getSimilarTags(tags, current.Tags), tags) {
+				result = append(result, *current)
+			}
+
+			continue
+		}
+
+		result = append(result, role)
+	}
+
+	for _, roleName := range sortedNames(m.roles) {
+		current := m.roles[roleName]
+		if _, ok := listed[roleName]; ok || current == nil {
+			continue
+		}
+
+		if compareTags(getSimilarTags(tags, current.Tags), tags) {
+			result = append(result, *current)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *DryRunIAMManager) TagPolicy(policyName *string, tags []iamType.Tag) error {
+	policy, exists, err := m.GetPolicyByName(policyName)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{Name: aws.ToString(policyName), Operation: ActionTag, ResourceType: ResourceTypePolicy, Tags: tags})
+	if exists {
+		policy.Tags = mergeTags(policy.Tags, tags)
+		m.policies[*policyName] = policy
+	}
+
+	return nil
+}
+
+func (m *DryRunIAMManager) TagRole(roleName *string, tags []iamType.Tag) error {
+	role, exists, err := m.GetRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Action{Name: aws.ToString(roleName), Operation: ActionTag, ResourceType: ResourceTypeRole, Tags: tags})
+	if exists {
+		role.Tags = mergeTags(role.Tags, tags)
+		m.roles[*roleName] = role
+	}
+
+	return nil
+}
+
+func (m *DryRunIAMManager) UpdateRole(roleName, assumeRolePolicyDocument *string) error {
+	role, exists, err := m.GetRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var previousDocument string
+	if exists {
+		// The trust relationship policy document of the remote role is URL encoded.
+		if previousDocument, err = url.PathUnescape(aws.ToString(role.AssumeRolePolicyDocument)); err != nil {
+			return err
+		}
+	}
+
+	m.record(Action{
+		Document:         aws.ToString(assumeRolePolicyDocument),
+		Name:             aws.ToString(roleName),
+		Operation:        ActionUpdate,
+		PreviousDocument: previousDocument,
+		ResourceType:     ResourceTypeRole,
+	})
+	if exists {
+		role.AssumeRolePolicyDocument = assumeRolePolicyDocument
+		m.roles[*roleName] = role
+	}
+
+	return nil
+}
+
+// mergeTags overrides the values of the existing tags and appends the new ones.
+func mergeTags(tags, newTags []iamType.Tag) []iamType.Tag {
+	result := append([]iamType.Tag{}, tags...)
+	for _, newTag := range newTags {
+		found := false
+		for num, tag := range result {
+			if aws.ToString(tag.Key) == aws.ToString(newTag.Key) {
+				result[num].Value = newTag.Value
+				found = true
+			}
+		}
+
+		if !found {
+			result = append(result, newTag)
+		}
+	}
+
+	return result
+}
+
+func sortedNames[V any](values map[string]V) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,171 @@
+package aws_sdk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+const testDocument = `{"Version":"2012-10-17","Statement":[]}`
+
+// fakeIAMManager - the remote state read by the dry run, the mutations are not expected to reach it.
+type fakeIAMManager struct {
+	IAMManager
+	documents map[string]string
+	metadata  *IAMClientMetadata
+	policies  map[string]iamType.Policy
+	roles     map[string]iamType.Role
+}
+
+func newFakeIAMManager(partition string) *fakeIAMManager {
+	return &fakeIAMManager{
+		documents: make(map[string]string),
+		metadata:  &IAMClientMetadata{AccountID: "123456789012", Partition: partition, PathPrefix: DefaultPathPrefix},
+		policies:  make(map[string]iamType.Policy),
+		roles:     make(map[string]iamType.Role),
+	}
+}
+
+func (f *fakeIAMManager) GetIAMClientMetadata() *IAMClientMetadata {
+	return f.metadata
+}
+
+func (f *fakeIAMManager) GetPolicyByName(policyName *string) (*iamType.Policy, bool, error) {
+	policy, ok := f.policies[*policyName]
+
+	return &policy, ok, nil
+}
+
+func (f *fakeIAMManager) GetPolicyDocument(policy *iamType.Policy) (*string, error) {
+	return aws.String(f.documents[*policy.PolicyName]), nil
+}
+
+func (f *fakeIAMManager) GetRoleByName(roleName *string) (*iamType.Role, bool, error) {
+	role, ok := f.roles[*roleName]
+
+	return &role, ok, nil
+}
+
+func (f *fakeIAMManager) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, error) {
+	var policies []iamType.Policy
+	for _, policyName := range sortedNames(f.policies) {
+		if policy := f.policies[policyName]; compareTags(getSimilarTags(tags, policy.Tags), tags) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, nil
+}
+
+func (f *fakeIAMManager) ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error) {
+	var roles []iamType.Role
+	for _, roleName := range sortedNames(f.roles) {
+		if role := f.roles[roleName]; compareTags(getSimilarTags(tags, role.Tags), tags) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+// TestDryRunARNPartition checks the ARNs of the resources planned by the dry run are built in the partition
+// of the caller identity.
+func TestDryRunARNPartition(t *testing.T) {
+	dryRun := NewDryRunIAMManager(newFakeIAMManager("aws-cn"))
+
+	policy, err := dryRun.CreatePolicy(aws.String("policy"), aws.String(testDocument), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role, err := dryRun.CreateRole(aws.String("role"), aws.String(testDocument), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for arn, expected := range map[string]string{
+		*policy.Arn: "arn:aws-cn:iam::123456789012:policy" + DefaultPathPrefix + "policy",
+		*role.Arn:   "arn:aws-cn:iam::123456789012:role" + DefaultPathPrefix + "role",
+	} {
+		if arn != expected {
+			t.Errorf("expected ARN %s, got %s", expected, arn)
+		}
+	}
+}
+
+// TestDryRunActions checks the mutations are recorded in the order of application instead of being performed,
+// the recreation of a policy is presented as an update and the subsequent reads reflect the mutations.
+func TestDryRunActions(t *testing.T) {
+	owner := newTestOwner()
+	manager := newFakeIAMManager("aws")
+	manager.policies["policy"] = iamType.Policy{PolicyName: aws.String("policy"), Tags: TagsDefine(owner)}
+	manager.documents["policy"] = testDocument
+	manager.roles["stale"] = iamType.Role{RoleName: aws.String("stale"), Tags: TagsDefine(owner)}
+	dryRun := NewDryRunIAMManager(manager)
+
+	newDocument := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`
+	if _, err := dryRun.CreateRole(aws.String("role"), aws.String(testDocument), nil, TagsDefine(owner)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dryRun.DeletePolicy(aws.String("policy")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dryRun.CreatePolicy(aws.String("policy"), aws.String(newDocument), nil, TagsDefine(owner)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dryRun.AttachRolePolicy(aws.String("policy"), aws.String("role")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dryRun.DeleteRole(aws.String("stale")); err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	for _, action := range dryRun.Actions() {
+		actions = append(actions, action.String())
+	}
+
+	expected := []string{
+		"Delete Role stale",
+		"Create Role role",
+		"Update Policy policy",
+		"Attach Policy policy, role role",
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("expected actions %v, got %v", expected, actions)
+	}
+
+	for _, action := range dryRun.Actions() {
+		if action.Operation == ActionUpdate && (action.PreviousDocument != testDocument || action.Document != newDocument) {
+			t.Errorf("expected the update of the policy from %s to %s, got %+v", testDocument, newDocument, action)
+		}
+	}
+
+	roles, err := dryRun.ListRolesByTags(owner.IdentityTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roles) != 1 || *roles[0].RoleName != "role" {
+		t.Errorf("expected the listed roles to reflect the creation and deletion, got %v", roles)
+	}
+
+	attached, err := dryRun.ListAttachedRolePolicies(aws.String("role"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attached) != 1 || *attached[0].PolicyName != "policy" {
+		t.Errorf("expected the policy attached to the role, got %v", attached)
+	}
+
+	if manager.documents["policy"] != testDocument || len(manager.roles) != 1 {
+		t.Error("expected the remote state to be unchanged")
+	}
+}
@@ -0,0 +1,48 @@
+package aws_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksType "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// ErrEKSClusterNotFound - the EKS cluster does not exist in the region.
+var ErrEKSClusterNotFound = errors.New("EKS cluster not found")
+
+// EKSCluster - the attributes of the EKS cluster returned by DescribeCluster.
+type EKSCluster struct {
+	// OIDCIssuerURL - e.g. https://oidc.eks.eu-central-1.amazonaws.com/id/EXAMPLED539D4633E53DE1B71EXAMPLE.
+	OIDCIssuerURL string
+	// Status - one of CREATING, ACTIVE, DELETING, FAILED, UPDATING or PENDING.
+	Status string
+}
+
+// DescribeEKSCluster describes the EKS cluster with the AWS credentials of the default credential chain.
+func DescribeEKSCluster(ctx context.Context, region, name string) (*EKSCluster, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := eks.NewFromConfig(cfg).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(name)})
+	if err != nil {
+		var notFound *eksType.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %s in %s", ErrEKSClusterNotFound, name, region)
+		}
+
+		return nil, err
+	}
+
+	cluster := &EKSCluster{Status: string(output.Cluster.Status)}
+	if output.Cluster.Identity != nil && output.Cluster.Identity.Oidc != nil {
+		cluster.OIDCIssuerURL = aws.ToString(output.Cluster.Identity.Oidc.Issuer)
+	}
+
+	return cluster, nil
+}
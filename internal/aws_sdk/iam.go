@@ -1,7 +1,11 @@
 package aws_sdk
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
@@ -11,8 +15,8 @@ import (
 )
 
 const (
-	IAMDescription = `Do not change the tag values, as this may affect work of the operator. If you need to add tags, do so through the AWSIAMProvision custom resource.`
-	pathPrefix     = "/aws-iam-provisioner/"
+	DefaultPathPrefix = "/aws-iam-provisioner/"
+	IAMDescription    = `Do not change the tag values, as this may affect work of the operator. If you need to add tags, do so through the AWSIAMProvision custom resource.`
 )
 
 type IAMManager interface {
@@ -27,11 +31,14 @@ type IAMManager interface {
 	DiffRoleByPolicyDocument(rolePolicyDocumentA, rolePolicyDocumentB *string) (bool, error)
 	GetIAMClientMetadata() *IAMClientMetadata
 	GetPolicyByName(policyName *string) (*iamType.Policy, bool, error)
+	GetPolicyDocument(policy *iamType.Policy) (*string, error)
 	GetRoleByName(roleName *string) (*iamType.Role, bool, error)
 	ListAttachedRolePolicies(roleName *string) ([]iamType.Policy, error)
 	ListEntitiesForPolicy(policy *iamType.Policy) ([]iamType.PolicyRole, error)
 	ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, error)
 	ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error)
+	TagPolicy(policyName *string, tags []iamType.Tag) error
+	TagRole(roleName *string, tags []iamType.Tag) error
 	UpdateRole(roleName, assumeRolePolicyDocument *string) error
 }
 
@@ -44,11 +51,55 @@ type IAMClient struct {
 
 type IAMClientMetadata struct {
 	AccountID string
-	Region    string
+	// Partition - the AWS partition of the caller identity, `aws` if it is unknown.
+	Partition string
+	// PathPrefix - IAM path used for creation, listing and ARN generation of the roles and policies.
+	PathPrefix string
+	Region     string
+}
+
+// PolicyARN returns the ARN of the customer managed policy created by the client.
+func (m *IAMClientMetadata) PolicyARN(policyName string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:policy%s%s", m.GetPartition(), m.AccountID, m.PathPrefix, policyName)
 }
 
-func NewIAMClient(region string, logger logr.Logger) (*IAMClient, error) {
-	ctx := context.TODO()
+// RoleARN returns the ARN of the role created by the client.
+func (m *IAMClientMetadata) RoleARN(roleName string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:role%s%s", m.GetPartition(), m.AccountID, m.PathPrefix, roleName)
+}
+
+// GetPartition returns the AWS partition which the ARNs of the client are built in.
+func (m *IAMClientMetadata) GetPartition() string {
+	if len(m.Partition) == 0 {
+		return "aws"
+	}
+
+	return m.Partition
+}
+
+// callerConfig - the AWS config of the default credential chain for a region and the identity of its credentials.
+type callerConfig struct {
+	cfg      aws.Config
+	identity *sts.GetCallerIdentityOutput
+}
+
+// callerConfigs - the configs by the regions, the credential chain of the operator does not change during
+// the process lifetime, the credentials themselves are refreshed by the credentials cache of the config.
+var callerConfigs = struct {
+	mu       sync.Mutex
+	byRegion map[string]*callerConfig
+}{byRegion: make(map[string]*callerConfig)}
+
+// getCallerConfig loads the config of the region and resolves the caller identity once per process,
+// the failures are not cached, so the invalid credentials are retried by the next reconciliation.
+func getCallerConfig(ctx context.Context, region string) (*callerConfig, error) {
+	callerConfigs.mu.Lock()
+	defer callerConfigs.mu.Unlock()
+
+	if cached, ok := callerConfigs.byRegion[region]; ok {
+		return cached, nil
+	}
+
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
 	if err != nil {
 		return nil, err
@@ -59,11 +110,43 @@ func NewIAMClient(region string, logger logr.Logger) (*IAMClient, error) {
 		return nil, err
 	}
 
+	cached := &callerConfig{cfg: cfg, identity: identity}
+	callerConfigs.byRegion[region] = cached
+
+	return cached, nil
+}
+
+// NewIAMClient creates the AWS IAM client, all API calls of the client are bound to the context.
+func NewIAMClient(ctx context.Context, region, pathPrefix string, logger logr.Logger) (*IAMClient, error) {
+	if len(pathPrefix) == 0 {
+		pathPrefix = DefaultPathPrefix
+	}
+
+	caller, err := getCallerConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := caller.cfg
+	accountID := aws.ToString(caller.identity.Account)
+	var partition string
+	if callerARN, err := arn.Parse(aws.ToString(caller.identity.Arn)); err == nil {
+		partition = callerARN.Partition
+	}
+
 	return &IAMClient{
-		Ctx:               ctx,
-		IAMClient:         iam.NewFromConfig(cfg),
-		IAMClientMetadata: &IAMClientMetadata{AccountID: aws.ToString(identity.Account), Region: region},
-		Logger:            logger,
+		Ctx: ctx,
+		IAMClient: iam.NewFromConfig(cfg, func(options *iam.Options) {
+			options.APIOptions = append(options.APIOptions,
+				addMetricsMiddleware, newRateLimitMiddleware(getRateLimiter(accountID)))
+		}),
+		IAMClientMetadata: &IAMClientMetadata{
+			AccountID:  accountID,
+			Partition:  partition,
+			PathPrefix: pathPrefix,
+			Region:     region,
+		},
+		Logger: logger,
 	}, nil
 }
 
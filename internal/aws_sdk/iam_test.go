@@ -0,0 +1,47 @@
+package aws_sdk
+
+import (
+	"testing"
+)
+
+// TestIAMClientMetadataARN checks the ARNs of the roles and policies are built in the partition of the caller identity
+// under the IAM path of the client.
+func TestIAMClientMetadataARN(t *testing.T) {
+	for name, test := range map[string]struct {
+		metadata          IAMClientMetadata
+		expectedPartition string
+		expectedPolicyARN string
+		expectedRoleARN   string
+	}{
+		"unknown partition": {
+			metadata:          IAMClientMetadata{AccountID: "123456789012", PathPrefix: DefaultPathPrefix},
+			expectedPartition: "aws",
+			expectedPolicyARN: "arn:aws:iam::123456789012:policy/aws-iam-provisioner/name",
+			expectedRoleARN:   "arn:aws:iam::123456789012:role/aws-iam-provisioner/name",
+		},
+		"China": {
+			metadata:          IAMClientMetadata{AccountID: "123456789012", Partition: "aws-cn", PathPrefix: "/staging/"},
+			expectedPartition: "aws-cn",
+			expectedPolicyARN: "arn:aws-cn:iam::123456789012:policy/staging/name",
+			expectedRoleARN:   "arn:aws-cn:iam::123456789012:role/staging/name",
+		},
+		"GovCloud": {
+			metadata:          IAMClientMetadata{AccountID: "123456789012", Partition: "aws-us-gov", PathPrefix: "/"},
+			expectedPartition: "aws-us-gov",
+			expectedPolicyARN: "arn:aws-us-gov:iam::123456789012:policy/name",
+			expectedRoleARN:   "arn:aws-us-gov:iam::123456789012:role/name",
+		},
+	} {
+		if partition := test.metadata.GetPartition(); partition != test.expectedPartition {
+			t.Errorf("%s: expected partition %s, got %s", name, test.expectedPartition, partition)
+		}
+
+		if policyARN := test.metadata.PolicyARN("name"); policyARN != test.expectedPolicyARN {
+			t.Errorf("%s: expected policy ARN %s, got %s", name, test.expectedPolicyARN, policyARN)
+		}
+
+		if roleARN := test.metadata.RoleARN("name"); roleARN != test.expectedRoleARN {
+			t.Errorf("%s: expected role ARN %s, got %s", name, test.expectedRoleARN, roleARN)
+		}
+	}
+}
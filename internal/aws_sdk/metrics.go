@@ -0,0 +1,35 @@
+package aws_sdk
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+
+	"aws-iam-provisioner.operators.infra/internal/metrics"
+)
+
+// addMetricsMiddleware instruments every AWS IAM API call including pagination,
+// it is added before the retry middleware to measure the latency of a call with all its attempts.
+func addMetricsMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("AWSIAMProvisionerMetrics",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+			middleware.FinalizeOutput, middleware.Metadata, error,
+		) {
+			operation := awsmiddleware.GetOperationName(ctx)
+			start := time.Now()
+
+			out, metadata, err := next.HandleFinalize(ctx, in)
+
+			result := metrics.ResultSuccess
+			if err != nil {
+				result = metrics.ResultError
+			}
+
+			metrics.IAMAPICalls.WithLabelValues(operation, result).Inc()
+			metrics.IAMAPICallDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+			return out, metadata, err
+		}), middleware.Before)
+}
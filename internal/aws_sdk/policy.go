@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
@@ -16,9 +17,7 @@ const (
 )
 
 func (c *IAMClient) generatePolicyARN(policyName *string) *string {
-	policyARN := fmt.Sprintf("arn:aws:iam::%s:policy%s%s", c.AccountID, pathPrefix, *policyName)
-
-	return &policyARN
+	return aws.String(c.PolicyARN(*policyName))
 }
 
 func (c *IAMClient) BatchDeletePolicies(policies []iamType.Policy) error {
@@ -34,7 +33,7 @@ func (c *IAMClient) BatchDeletePolicies(policies []iamType.Policy) error {
 func (c *IAMClient) CreatePolicy(policyName, policyData, description *string, tags []iamType.Tag) (*iamType.Policy, error) {
 	result, err := c.IAMClient.CreatePolicy(c.Ctx, &iam.CreatePolicyInput{
 		Description:    description,
-		Path:           aws.String(pathPrefix),
+		Path:           aws.String(c.PathPrefix),
 		PolicyDocument: policyData,
 		PolicyName:     policyName,
 		Tags:           tags,
@@ -99,6 +98,24 @@ func (c *IAMClient) GetPolicyByName(policyName *string) (*iamType.Policy, bool,
 	return result.Policy, true, nil
 }
 
+// GetPolicyDocument returns the decoded document of the default version of the policy.
+func (c *IAMClient) GetPolicyDocument(policy *iamType.Policy) (*string, error) {
+	result, err := c.IAMClient.GetPolicyVersion(c.Ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: policy.Arn,
+		VersionId: policy.DefaultVersionId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := url.PathUnescape(aws.ToString(result.PolicyVersion.Document))
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
 func (c *IAMClient) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, error) {
 	var (
 		params   *iam.ListPoliciesInput
@@ -107,7 +124,7 @@ func (c *IAMClient) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, er
 
 	params = &iam.ListPoliciesInput{
 		MaxItems:          aws.Int32(50),
-		PathPrefix:        aws.String(pathPrefix),
+		PathPrefix:        aws.String(c.PathPrefix),
 		PolicyUsageFilter: iamType.PolicyUsageTypePermissionsPolicy,
 		Scope:             iamType.PolicyScopeTypeLocal,
 	}
@@ -120,12 +137,12 @@ func (c *IAMClient) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, er
 			return nil, err
 		} else {
 			for _, policy := range result.Policies {
-				similarTags, err := c.getSimilarPolicyTags(tags, policy)
+				policy.Tags, err = c.listPolicyTags(policy)
 				if err != nil {
 					return nil, err
 				}
 
-				if compareTags(similarTags, tags) {
+				if compareTags(getSimilarTags(tags, policy.Tags), tags) {
 					policies = append(policies, policy)
 				}
 			}
@@ -145,7 +162,7 @@ func (c *IAMClient) ListEntitiesForPolicy(policy *iamType.Policy) ([]iamType.Pol
 		PolicyArn:         policy.Arn,
 		EntityFilter:      iamType.EntityTypeRole,
 		MaxItems:          aws.Int32(10),
-		PathPrefix:        aws.String(pathPrefix),
+		PathPrefix:        aws.String(c.PathPrefix),
 		PolicyUsageFilter: iamType.PolicyUsageTypePermissionsPolicy,
 	}
 
@@ -0,0 +1,63 @@
+package aws_sdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultRateLimitBurst = 20
+	DefaultRateLimitQPS   = 10
+)
+
+var (
+	rateLimitBurst = DefaultRateLimitBurst
+	rateLimitQPS   = rate.Limit(DefaultRateLimitQPS)
+	rateLimiters   = make(map[string]*rate.Limiter)
+	rateLimitersMu sync.Mutex
+)
+
+// SetRateLimit sets the client-side rate limit of AWS IAM API calls per AWS account,
+// it should be called before the creation of the first IAM client.
+func SetRateLimit(qps float64, burst int) {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	rateLimitQPS = rate.Limit(qps)
+	rateLimitBurst = burst
+}
+
+// getRateLimiter returns the rate limiter of the AWS account,
+// the limiter is shared by all IAM clients of the account regardless of the reconciliation.
+func getRateLimiter(accountID string) *rate.Limiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	limiter, ok := rateLimiters[accountID]
+	if !ok {
+		limiter = rate.NewLimiter(rateLimitQPS, rateLimitBurst)
+		rateLimiters[accountID] = limiter
+	}
+
+	return limiter
+}
+
+// newRateLimitMiddleware delays every attempt of an AWS IAM API call including retries,
+// so the parallel reconciliations do not exceed the rate limit of the account.
+func newRateLimitMiddleware(limiter *rate.Limiter) func(stack *middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("AWSIAMProvisionerRateLimit",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+				middleware.FinalizeOutput, middleware.Metadata, error,
+			) {
+				if err := limiter.Wait(ctx); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+
+				return next.HandleFinalize(ctx, in)
+			}), middleware.After)
+	}
+}
@@ -70,7 +70,7 @@ func (c *IAMClient) CreateRole(roleName, assumeRolePolicyDocument, description *
 		AssumeRolePolicyDocument: assumeRolePolicyDocument,
 		Description:              description,
 		RoleName:                 roleName,
-		Path:                     aws.String(pathPrefix),
+		Path:                     aws.String(c.PathPrefix),
 		Tags:                     tags,
 	})
 	if err != nil {
@@ -212,7 +212,7 @@ func (c *IAMClient) ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error)
 
 	params = &iam.ListRolesInput{
 		MaxItems:   aws.Int32(50),
-		PathPrefix: aws.String(pathPrefix),
+		PathPrefix: aws.String(c.PathPrefix),
 	}
 
 	rolePaginator := iam.NewListRolesPaginator(c.IAMClient, params,
@@ -223,12 +223,12 @@ func (c *IAMClient) ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error)
 			return nil, err
 		} else {
 			for _, role := range result.Roles {
-				similarTags, err := c.getSimilarRoleTags(tags, role)
+				role.Tags, err = c.listRoleTags(role)
 				if err != nil {
 					return nil, err
 				}
 
-				if compareTags(similarTags, tags) {
+				if compareTags(getSimilarTags(tags, role.Tags), tags) {
 					roles = append(roles, role)
 				}
 			}
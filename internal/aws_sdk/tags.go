@@ -14,10 +14,92 @@ import (
 
 const (
 	TagKeyEKSClusterName = "aws.edenlab.io/aws-iam-provisioner/cluster"
+	TagKeyName           = "aws.edenlab.io/aws-iam-provisioner/name"
 	TagKeyNamespace      = "aws.edenlab.io/aws-iam-provisioner/namespace"
 	TagKeyPolicyDocument = "aws.edenlab.io/aws-iam-provisioner/checksum"
+	TagKeyUID            = "aws.edenlab.io/aws-iam-provisioner/uid"
 )
 
+// Ownership of an IAM resource by an AWSIAMProvision CR.
+type Ownership int
+
+const (
+	// NotOwned - the resource has no ownership tags, e.g. it was created manually.
+	NotOwned Ownership = iota
+	// Owned - the resource is owned by the CR.
+	Owned
+	// LegacyOwned - the resource is tagged only by the cluster and namespace of the CR,
+	// it was created before the CR identity tags were introduced.
+	LegacyOwned
+	// OwnedByOther - the resource is owned by another CR.
+	OwnedByOther
+)
+
+// ResourceOwner - identity of the AWSIAMProvision CR which owns the IAM resources.
+type ResourceOwner struct {
+	ClusterName string
+	Name        string
+	Namespace   string
+	UID         string
+}
+
+// GetOwnership determines whether the IAM resource with the tags is owned by the CR.
+func (o *ResourceOwner) GetOwnership(tags []iamType.Tag) Ownership {
+	values := make(map[string]string)
+	for _, tag := range tags {
+		values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	if uid, ok := values[TagKeyUID]; ok {
+		if uid == o.UID {
+			return Owned
+		}
+
+		return OwnedByOther
+	}
+
+	clusterName, clusterNameFound := values[TagKeyEKSClusterName]
+	namespace, namespaceFound := values[TagKeyNamespace]
+	if !clusterNameFound && !namespaceFound {
+		return NotOwned
+	}
+
+	if clusterName == o.ClusterName && namespace == o.Namespace {
+		return LegacyOwned
+	}
+
+	return OwnedByOther
+}
+
+// IdentityTags returns the tags which identify the CR, they are used to adopt the legacy owned resources.
+func (o *ResourceOwner) IdentityTags() []iamType.Tag {
+	return []iamType.Tag{
+		{
+			Key:   aws.String(TagKeyName),
+			Value: aws.String(o.Name),
+		},
+		{
+			Key:   aws.String(TagKeyUID),
+			Value: aws.String(o.UID),
+		},
+	}
+}
+
+// LegacyTags returns the tags of the cluster and namespace of the CR, the resources tagged only by them are adopted
+// by the CR. They are used to hand over the resources created by other tools to the operator.
+func (o *ResourceOwner) LegacyTags() []iamType.Tag {
+	return []iamType.Tag{
+		{
+			Key:   aws.String(TagKeyEKSClusterName),
+			Value: aws.String(o.ClusterName),
+		},
+		{
+			Key:   aws.String(TagKeyNamespace),
+			Value: aws.String(o.Namespace),
+		},
+	}
+}
+
 func compareTags(tagsA, tagsB []iamType.Tag) bool {
 	return cmp.Equal(tagsA, tagsB, cmp.AllowUnexported(iamType.Tag{}))
 }
@@ -42,17 +124,8 @@ func ConvertToIAMTags(specTags []*iamv1alpha1.Tag) []iamType.Tag {
 	return iamTags
 }
 
-func TagsDefine(clusterName, namespace string, tags ...iamType.Tag) []iamType.Tag {
-	return append([]iamType.Tag{
-		{
-			Key:   aws.String(TagKeyEKSClusterName),
-			Value: aws.String(clusterName),
-		},
-		{
-			Key:   aws.String(TagKeyNamespace),
-			Value: aws.String(namespace),
-		},
-	}, tags...)
+func TagsDefine(owner *ResourceOwner, tags ...iamType.Tag) []iamType.Tag {
+	return append(append(owner.LegacyTags(), owner.IdentityTags()...), tags...)
 }
 
 func getSimilarTags(compareTags, resultTags []iamType.Tag) []iamType.Tag {
@@ -68,10 +141,11 @@ func getSimilarTags(compareTags, resultTags []iamType.Tag) []iamType.Tag {
 	return similarTags
 }
 
-func (c *IAMClient) getSimilarPolicyTags(compareTags []iamType.Tag, policy iamType.Policy) ([]iamType.Tag, error) {
+// listPolicyTags returns the tags of the policy, ListPolicies does not return them.
+func (c *IAMClient) listPolicyTags(policy iamType.Policy) ([]iamType.Tag, error) {
 	resultTags, err := c.IAMClient.ListPolicyTags(c.Ctx,
 		&iam.ListPolicyTagsInput{
-			MaxItems:  aws.Int32(10),
+			MaxItems:  aws.Int32(50),
 			PolicyArn: policy.Arn,
 		},
 	)
@@ -79,13 +153,14 @@ func (c *IAMClient) getSimilarPolicyTags(compareTags []iamType.Tag, policy iamTy
 		return nil, err
 	}
 
-	return getSimilarTags(compareTags, resultTags.Tags), nil
+	return resultTags.Tags, nil
 }
 
-func (c *IAMClient) getSimilarRoleTags(compareTags []iamType.Tag, role iamType.Role) ([]iamType.Tag, error) {
+// listRoleTags returns the tags of the role, ListRoles does not return them.
+func (c *IAMClient) listRoleTags(role iamType.Role) ([]iamType.Tag, error) {
 	resultTags, err := c.IAMClient.ListRoleTags(c.Ctx,
 		&iam.ListRoleTagsInput{
-			MaxItems: aws.Int32(10),
+			MaxItems: aws.Int32(50),
 			RoleName: role.RoleName,
 		},
 	)
@@ -93,5 +168,33 @@ func (c *IAMClient) getSimilarRoleTags(compareTags []iamType.Tag, role iamType.R
 		return nil, err
 	}
 
-	return getSimilarTags(compareTags, resultTags.Tags), nil
+	return resultTags.Tags, nil
+}
+
+func (c *IAMClient) TagPolicy(policyName *string, tags []iamType.Tag) error {
+	_, err := c.IAMClient.TagPolicy(c.Ctx, &iam.TagPolicyInput{
+		PolicyArn: c.generatePolicyARN(policyName),
+		Tags:      tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Info(fmt.Sprintf("tagged %s policy", *policyName))
+
+	return nil
+}
+
+func (c *IAMClient) TagRole(roleName *string, tags []iamType.Tag) error {
+	_, err := c.IAMClient.TagRole(c.Ctx, &iam.TagRoleInput{
+		RoleName: roleName,
+		Tags:     tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Info(fmt.Sprintf("tagged %s role", *roleName))
+
+	return nil
 }
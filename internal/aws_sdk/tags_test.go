@@ -0,0 +1,65 @@
+package aws_sdk
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+func newTestOwner() *ResourceOwner {
+	return &ResourceOwner{ClusterName: "cluster", Name: "provision", Namespace: "default", UID: "provision-uid"}
+}
+
+func newTag(key, value string) iamType.Tag {
+	return iamType.Tag{Key: aws.String(key), Value: aws.String(value)}
+}
+
+// TestGetOwnership checks the ownership of a resource is determined by the UID tag first,
+// then by the cluster and namespace tags of the resources created before the identity tags.
+func TestGetOwnership(t *testing.T) {
+	owner := newTestOwner()
+	otherCluster := &ResourceOwner{ClusterName: "other", Namespace: owner.Namespace}
+	otherNamespace := &ResourceOwner{ClusterName: owner.ClusterName, Namespace: "other"}
+	for name, test := range map[string]struct {
+		tags     []iamType.Tag
+		expected Ownership
+	}{
+		"no tags":         {expected: NotOwned},
+		"foreign tags":    {tags: []iamType.Tag{newTag("team", "platform")}, expected: NotOwned},
+		"all tags":        {tags: TagsDefine(owner), expected: Owned},
+		"UID only":        {tags: []iamType.Tag{newTag(TagKeyUID, owner.UID)}, expected: Owned},
+		"other UID":       {tags: append(owner.LegacyTags(), newTag(TagKeyUID, "other-uid")), expected: OwnedByOther},
+		"legacy":          {tags: owner.LegacyTags(), expected: LegacyOwned},
+		"other cluster":   {tags: otherCluster.LegacyTags(), expected: OwnedByOther},
+		"other namespace": {tags: otherNamespace.LegacyTags(), expected: OwnedByOther},
+		"cluster only":    {tags: []iamType.Tag{newTag(TagKeyEKSClusterName, owner.ClusterName)}, expected: OwnedByOther},
+	} {
+		if ownership := owner.GetOwnership(test.tags); ownership != test.expected {
+			t.Errorf("%s: expected ownership %d, got %d", name, test.expected, ownership)
+		}
+	}
+}
+
+// TestTagsDefine checks the resources are tagged by the legacy and identity tags of the owner followed by the extra tags.
+func TestTagsDefine(t *testing.T) {
+	owner := newTestOwner()
+	checksum := NewChecksumTag(aws.String(`{"Version":"2012-10-17","Statement":[]}`))
+	expected := []iamType.Tag{
+		newTag(TagKeyEKSClusterName, owner.ClusterName),
+		newTag(TagKeyNamespace, owner.Namespace),
+		newTag(TagKeyName, owner.Name),
+		newTag(TagKeyUID, owner.UID),
+		checksum,
+	}
+
+	if tags := TagsDefine(owner, checksum); !compareTags(tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, tags)
+	}
+
+	// The identity tags are a subset of the tags of the owned resources, the resources are listed by them.
+	tags := TagsDefine(owner)
+	if identityTags := owner.IdentityTags(); !compareTags(getSimilarTags(identityTags, tags), identityTags) {
+		t.Errorf("expected identity tags %v in %v", identityTags, tags)
+	}
+}
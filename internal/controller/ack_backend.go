@@ -0,0 +1,776 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+const (
+	// ackOwnerLabel - the label of the ACK Role and Policy CRs with the UID of their AWSIAMProvision,
+	// the name of the AWSIAMProvision may exceed the limit of the label values.
+	ackOwnerLabel = "iam.aws.edenlab.io/awsiamprovision-uid"
+	// ackOwnerNameAnnotation - the annotation of the ACK Role and Policy CRs with the name of their AWSIAMProvision,
+	// it was the label of the previous versions of the operator.
+	ackOwnerNameAnnotation = "iam.aws.edenlab.io/awsiamprovision"
+)
+
+// ackInvalidNameChars - the characters of the IAM names which are not allowed in the names of Kubernetes objects.
+var ackInvalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// ackObjectName returns the name of the ACK CR of the role or policy of the AWSIAMProvision.
+func ackObjectName(awsIAMProvision *iamv1alpha1.AWSIAMProvision, key string) string {
+	name := strings.Trim(ackInvalidNameChars.ReplaceAllString(
+		strings.ToLower(awsIAMProvision.Name+"-"+key), "-"), "-.")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-.")
+	}
+
+	return name
+}
+
+func (rm *ReconciliationManager) getBackend(air *awsIAMResources) string {
+	if len(air.awsIAMProvision.Spec.Backend) > 0 {
+		return air.awsIAMProvision.Spec.Backend
+	}
+
+	if len(rm.Backend) > 0 {
+		return rm.Backend
+	}
+
+	return iamv1alpha1.BackendIAM
+}
+
+// ackIAMClientMetadata returns the metadata of the roles and policies provisioned by ACK,
+// the AWS account is taken from the ARN of the OIDC provider of the cluster.
+func (rm *ReconciliationManager) ackIAMClientMetadata(air *awsIAMResources) (*aws_sdk.IAMClientMetadata, error) {
+	pathPrefix := rm.getIAMPathPrefix(air)
+	if len(pathPrefix) == 0 {
+		pathPrefix = aws_sdk.DefaultPathPrefix
+	}
+
+	// arn:<partition>:iam::<account>:oidc-provider/<issuer>
+	parts := strings.SplitN(air.cluster.OIDCProviderARN, ":", 6)
+	if len(parts) != 6 || len(parts[4]) == 0 {
+		return nil, fmt.Errorf("AWS account of %s AWSIAMProvision can not be derived for the ACK backend, "+
+			"the ARN of the OIDC provider of the cluster is required: %q", rm.request.NamespacedName, air.cluster.OIDCProviderARN)
+	}
+
+	return &aws_sdk.IAMClientMetadata{
+		AccountID:  parts[4],
+		Partition:  parts[1],
+		PathPrefix: pathPrefix,
+		Region:     air.awsIAMProvision.Spec.Region,
+	}, nil
+}
+
+func newACKTags(tags []*iamv1alpha1.Tag, owner *aws_sdk.ResourceOwner) []*ackiamv1alpha1.Tag {
+	var ackTags []*ackiamv1alpha1.Tag
+	for _, tag := range aws_sdk.TagsDefine(owner, aws_sdk.ConvertToIAMTags(tags)...) {
+		ackTags = append(ackTags, &ackiamv1alpha1.Tag{Key: tag.Key, Value: tag.Value})
+	}
+
+	return ackTags
+}
+
+// desiredACKResources renders the ACK Role and Policy CRs of the spec,
+// the errors of the roles and policies are aggregated.
+func (rm *ReconciliationManager) desiredACKResources(air *awsIAMResources,
+	metadata *aws_sdk.IAMClientMetadata) ([]*ackiamv1alpha1.Policy, []*ackiamv1alpha1.Role, error) {
+	var (
+		errs     []error
+		policies []*ackiamv1alpha1.Policy
+		roles    []*ackiamv1alpha1.Role
+	)
+
+	objectMeta := func(key string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:        ackObjectName(air.awsIAMProvision, key),
+			Namespace:   air.awsIAMProvision.Namespace,
+			Labels:      map[string]string{ackOwnerLabel: string(air.awsIAMProvision.UID)},
+			Annotations: map[string]string{ackOwnerNameAnnotation: air.awsIAMProvision.Name},
+		}
+	}
+
+	// The policies of the CR are referenced by the names of their ACK CRs, other policies by the ARNs.
+	policyObjects := make(map[string]string, len(air.spec.Policies))
+	for _, key := range sortedMapKeys(air.spec.Policies) {
+		policy := air.spec.Policies[key]
+		policyObjects[*policy.Spec.Name] = ackObjectName(air.awsIAMProvision, key)
+		if err := rm.setPolicyDocument(air, &policy); err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", *policy.Spec.Name, err))
+			continue
+		}
+
+		policies = append(policies, &ackiamv1alpha1.Policy{
+			ObjectMeta: objectMeta(key),
+			Spec: ackiamv1alpha1.PolicySpec{
+				Description: aws.String(fmt.Sprintf("%s%s. %s",
+					aws_sdk.PolicyDescriptionPrefix, air.awsIAMProvision.Spec.EKSClusterName, aws_sdk.IAMDescription)),
+				Name:           policy.Spec.Name,
+				Path:           aws.String(metadata.PathPrefix),
+				PolicyDocument: policy.Spec.PolicyDocument,
+				Tags:           newACKTags(policy.Spec.Tags, air.owner),
+			},
+		})
+	}
+
+	for _, key := range sortedMapKeys(air.spec.Roles) {
+		role := air.spec.Roles[key]
+		if err := rm.setAssumeRolePolicyDocument(air, &role); err != nil {
+			errs = append(errs, fmt.Errorf("role %s: %w", *role.Spec.Name, err))
+			continue
+		}
+
+		ackRole := &ackiamv1alpha1.Role{
+			ObjectMeta: objectMeta(key),
+			Spec: ackiamv1alpha1.RoleSpec{
+				AssumeRolePolicyDocument: role.Spec.AssumeRolePolicyDocument,
+				Description: aws.String(fmt.Sprintf("%s%s. %s",
+					aws_sdk.RoleDescriptionPrefix, air.awsIAMProvision.Spec.EKSClusterName, aws_sdk.IAMDescription)),
+				Name: role.Spec.Name,
+				Path: aws.String(metadata.PathPrefix),
+				Tags: newACKTags(role.Spec.Tags, air.owner),
+			},
+		}
+
+		for _, policyName := range role.Spec.Policies {
+			if objectName, ok := policyObjects[*policyName]; ok {
+				ackRole.Spec.PolicyRefs = append(ackRole.Spec.PolicyRefs, &ackv1alpha1.AWSResourceReferenceWrapper{
+					From: &ackv1alpha1.AWSResourceReference{Name: aws.String(objectName)},
+				})
+				continue
+			}
+
+			ackRole.Spec.Policies = append(ackRole.Spec.Policies, aws.String(metadata.PolicyARN(*policyName)))
+		}
+
+		roles = append(roles, ackRole)
+	}
+
+	return policies, roles, utilerrors.NewAggregate(errs)
+}
+
+// applyACKResource creates or updates the ACK CR owned by the CR, the current state of the ACK CR is read into
+// the object. In the Observe mode the ACK CR is only read and the required action is returned.
+func (rm *ReconciliationManager) applyACKResource(air *awsIAMResources, object client.Object,
+	setSpec func(existing client.Object) bool) (string, error) {
+	existing := object.DeepCopyObject().(client.Object)
+	if err := rm.Get(rm.ctx, client.ObjectKeyFromObject(object), existing); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+
+		if air.dryRun {
+			return aws_sdk.ActionCreate, nil
+		}
+
+		if err := ctrl.SetControllerReference(air.awsIAMProvision, object, rm.Scheme); err != nil {
+			return "", err
+		}
+
+		return aws_sdk.ActionCreate, rm.Create(rm.ctx, object)
+	}
+
+	if owner := metav1.GetControllerOf(existing); owner == nil || owner.UID != air.awsIAMProvision.UID {
+		return "", fmt.Errorf("%s %s is not owned by the AWSIAMProvision",
+			existing.GetObjectKind().GroupVersionKind().Kind, client.ObjectKeyFromObject(existing))
+	}
+
+	labels, annotations := existing.GetLabels(), existing.GetAnnotations()
+	_, legacyLabel := labels[ackOwnerNameAnnotation]
+	changed := setSpec(existing) || legacyLabel || labels[ackOwnerLabel] != string(air.awsIAMProvision.UID) ||
+		annotations[ackOwnerNameAnnotation] != air.awsIAMProvision.Name
+	if !changed {
+		return "", copyObject(existing, object)
+	}
+
+	if air.dryRun {
+		return aws_sdk.ActionUpdate, copyObject(existing, object)
+	}
+
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	delete(labels, ackOwnerNameAnnotation)
+	labels[ackOwnerLabel] = string(air.awsIAMProvision.UID)
+	annotations[ackOwnerNameAnnotation] = air.awsIAMProvision.Name
+	existing.SetLabels(labels)
+	existing.SetAnnotations(annotations)
+	if err := rm.Update(rm.ctx, existing); err != nil {
+		return "", err
+	}
+
+	return aws_sdk.ActionUpdate, copyObject(existing, object)
+}
+
+func copyObject(from, to client.Object) error {
+	switch obj := to.(type) {
+	case *ackiamv1alpha1.Role:
+		from.(*ackiamv1alpha1.Role).DeepCopyInto(obj)
+	case *ackiamv1alpha1.Policy:
+		from.(*ackiamv1alpha1.Policy).DeepCopyInto(obj)
+	default:
+		return fmt.Errorf("unsupported ACK resource %T", to)
+	}
+
+	return nil
+}
+
+// deleteStaleACKResources deletes the ACK CRs of the CR which are not in the spec anymore,
+// ACK deletes their AWS IAM resources.
+func (rm *ReconciliationManager) deleteStaleACKResources(air *awsIAMResources, policies []*ackiamv1alpha1.Policy,
+	roles []*ackiamv1alpha1.Role) ([]iamv1alpha1.AWSIAMProvisionPendingAction, error) {
+	desired := make(map[string]struct{})
+	for _, policy := range policies {
+		desired["Policy/"+policy.Name] = struct{}{}
+	}
+
+	for _, role := range roles {
+		desired["Role/"+role.Name] = struct{}{}
+	}
+
+	// The ACK CRs are selected by the controller reference, the ACK CRs created by the previous versions
+	// of the operator are labeled by the name of the CR.
+	var stale []client.Object
+	policyList := &ackiamv1alpha1.PolicyList{}
+	if err := rm.List(rm.ctx, policyList, client.InNamespace(air.awsIAMProvision.Namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range policyList.Items {
+		if _, ok := desired["Policy/"+policyList.Items[i].Name]; !ok &&
+			metav1.IsControlledBy(&policyList.Items[i], air.awsIAMProvision) {
+			stale = append(stale, &policyList.Items[i])
+		}
+	}
+
+	roleList := &ackiamv1alpha1.RoleList{}
+	if err := rm.List(rm.ctx, roleList, client.InNamespace(air.awsIAMProvision.Namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range roleList.Items {
+		if _, ok := desired["Role/"+roleList.Items[i].Name]; !ok &&
+			metav1.IsControlledBy(&roleList.Items[i], air.awsIAMProvision) {
+			stale = append(stale, &roleList.Items[i])
+		}
+	}
+
+	var (
+		actions []iamv1alpha1.AWSIAMProvisionPendingAction
+		errs    []error
+	)
+
+	for _, object := range stale {
+		if !metav1.IsControlledBy(object, air.awsIAMProvision) {
+			continue
+		}
+
+		action := iamv1alpha1.AWSIAMProvisionPendingAction{Action: aws_sdk.ActionDelete}
+		switch obj := object.(type) {
+		case *ackiamv1alpha1.Role:
+			action.Name, action.ResourceType = aws.ToString(obj.Spec.Name), aws_sdk.ResourceTypeRole
+		case *ackiamv1alpha1.Policy:
+			action.Name, action.ResourceType = aws.ToString(obj.Spec.Name), aws_sdk.ResourceTypePolicy
+		}
+
+		if air.dryRun {
+			actions = append(actions, action)
+			continue
+		}
+
+		if err := rm.Delete(rm.ctx, object); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", strings.ToLower(action.ResourceType), action.Name, err))
+			continue
+		}
+
+		rm.recordEvent(air, corev1.EventTypeNormal, deletePhase,
+			fmt.Sprintf("ACK %s %s was deleted.", action.ResourceType, client.ObjectKeyFromObject(object)))
+	}
+
+	return actions, utilerrors.NewAggregate(errs)
+}
+
+// newACKResourceConditions converts the conditions of the ACK CR to the conditions of the role or policy status.
+func newACKResourceConditions(ackConditions []*ackv1alpha1.Condition, generation int64) []metav1.Condition {
+	var conditions []metav1.Condition
+	for _, ackCondition := range ackConditions {
+		if ackCondition == nil {
+			continue
+		}
+
+		reason := aws.ToString(ackCondition.Reason)
+		if len(reason) == 0 {
+			reason = strings.TrimPrefix(string(ackCondition.Type), "ACK.")
+		}
+
+		condition := metav1.Condition{
+			Type:               string(ackCondition.Type),
+			Status:             metav1.ConditionStatus(ackCondition.Status),
+			ObservedGeneration: generation,
+			Reason:             ackConditionReason(reason),
+			Message:            aws.ToString(ackCondition.Message),
+		}
+
+		if ackCondition.LastTransitionTime != nil {
+			condition.LastTransitionTime = *ackCondition.LastTransitionTime
+		}
+
+		meta.SetStatusCondition(&conditions, condition)
+	}
+
+	sort.Slice(conditions, func(i, j int) bool {
+		return conditions[i].Type < conditions[j].Type
+	})
+
+	return conditions
+}
+
+// maxConditionReasonLength - the maximum length of the reason of metav1.Condition.
+const maxConditionReasonLength = 1024
+
+// ackConditionReason converts the free-form reason of the ACK condition to the CamelCase reason of metav1.Condition,
+// the characters other than letters, digits and underscores are dropped and start a new word.
+func ackConditionReason(reason string) string {
+	var (
+		builder   strings.Builder
+		wordStart = true
+	)
+
+	for _, char := range reason {
+		switch {
+		case char == '_' || '0' <= char && char <= '9':
+			// The reason must start with a letter.
+			if builder.Len() > 0 {
+				builder.WriteRune(char)
+			}
+		case 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z':
+			if wordStart {
+				char = unicode.ToUpper(char)
+			}
+
+			builder.WriteRune(char)
+		default:
+			wordStart = true
+			continue
+		}
+
+		wordStart = false
+	}
+
+	switch {
+	case builder.Len() == 0:
+		return iamv1alpha1.ConditionReasonACKCondition
+	case builder.Len() > maxConditionReasonLength:
+		return builder.String()[:maxConditionReasonLength]
+	}
+
+	return builder.String()
+}
+
+// ackSynced returns whether the ACK CR is synced with AWS IAM and the message of its latest failure.
+func ackSynced(conditions []*ackv1alpha1.Condition) (bool, string) {
+	var (
+		message string
+		synced  bool
+	)
+
+	for _, condition := range conditions {
+		if condition == nil {
+			continue
+		}
+
+		switch condition.Type {
+		case ackv1alpha1.ConditionTypeResourceSynced:
+			synced = condition.Status == corev1.ConditionTrue
+			if !synced && len(message) == 0 {
+				message = aws.ToString(condition.Message)
+			}
+		case ackv1alpha1.ConditionTypeTerminal, ackv1alpha1.ConditionTypeRecoverable:
+			if condition.Status == corev1.ConditionTrue {
+				message = aws.ToString(condition.Message)
+			}
+		}
+	}
+
+	return synced, message
+}
+
+func newACKResourceMetadata(metadata *ackv1alpha1.ResourceMetadata) *iamv1alpha1.AWSIAMResourceMetadata {
+	if metadata == nil {
+		return nil
+	}
+
+	resourceMetadata := &iamv1alpha1.AWSIAMResourceMetadata{}
+	if metadata.ARN != nil {
+		arn := iamv1alpha1.AWSResourceName(*metadata.ARN)
+		resourceMetadata.ARN = &arn
+	}
+
+	if metadata.OwnerAccountID != nil {
+		ownerAccountID := iamv1alpha1.AWSAccountID(*metadata.OwnerAccountID)
+		resourceMetadata.OwnerAccountID = &ownerAccountID
+	}
+
+	if metadata.Region != nil {
+		region := iamv1alpha1.AWSRegion(*metadata.Region)
+		resourceMetadata.Region = &region
+	}
+
+	return resourceMetadata
+}
+
+func int64ToInt32(value *int64) *int32 {
+	if value == nil {
+		return nil
+	}
+
+	result := int32(*value)
+
+	return &result
+}
+
+func ackPhase(synced bool) string {
+	if synced {
+		return provisionPhase
+	}
+
+	return provisionIntermediatePhase
+}
+
+// reportACKAction records the action applied to the ACK CR as an event or as a pending action in the Observe mode.
+func (rm *ReconciliationManager) reportACKAction(air *awsIAMResources, action, resourceType, name string,
+	pendingActions *[]iamv1alpha1.AWSIAMProvisionPendingAction) {
+	if len(action) == 0 {
+		return
+	}
+
+	if air.dryRun {
+		*pendingActions = append(*pendingActions, iamv1alpha1.AWSIAMProvisionPendingAction{
+			Action: action, Name: name, ResourceType: resourceType,
+		})
+
+		return
+	}
+
+	phase := updatePhase
+	if action == aws_sdk.ActionCreate {
+		phase = createPhase
+	}
+
+	rm.recordEvent(air, corev1.EventTypeNormal, phase,
+		fmt.Sprintf("ACK %s of %s %s was %s.", resourceType, strings.ToLower(resourceType), name, strings.ToLower(phase)))
+}
+
+// reconcileACK provisions the roles and policies of the CR by the ACK Role and Policy CRs.
+// The ACK CRs are owned by the CR, so they are garbage collected with it and ACK deletes the AWS IAM resources.
+func (rm *ReconciliationManager) reconcileACK(air *awsIAMResources) (ctrl.Result, error) {
+	meta.RemoveStatusCondition(&air.awsIAMProvision.Status.Conditions, iamv1alpha1.ConditionTypeCredentialsValid)
+	air.dryRun = rm.getMode(air) == iamv1alpha1.ModeObserve
+
+	fail := func(err error) (ctrl.Result, error) {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonSyncFailed, err.Error())
+		rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+
+		return ctrl.Result{}, err
+	}
+
+	// The ACK CRs are deleted by the garbage collector, the finalizer is only needed to unbind the ServiceAccounts.
+	if !air.awsIAMProvision.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName) {
+			if err := rm.syncServiceAccounts(air, true); err != nil {
+				return fail(err)
+			}
+
+			controllerutil.RemoveFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName)
+			if err := rm.Update(rm.ctx, air.awsIAMProvision); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !air.dryRun && hasServiceAccounts(air) &&
+		!controllerutil.ContainsFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName) {
+		// The update overwrites the status of the object by the remote one, the accumulated status is restored.
+		status := air.awsIAMProvision.Status.DeepCopy()
+		controllerutil.AddFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName)
+		if err := rm.Update(rm.ctx, air.awsIAMProvision); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		air.awsIAMProvision.Status = *status
+	}
+
+	metadata, err := rm.ackIAMClientMetadata(air)
+	if err != nil {
+		return fail(err)
+	}
+
+	air.templateData = newDocumentTemplateData(air, metadata)
+	policies, roles, err := rm.desiredACKResources(air, metadata)
+	if err != nil {
+		return fail(err)
+	}
+
+	documentsHash, err := ackDocumentsHash(policies, roles)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The plan observed in the Observe mode is applied only if it is still the plan of the CR,
+	// the plan is recomputed by reading the ACK CRs only.
+	if !air.dryRun && len(air.awsIAMProvision.Status.PlanHash) > 0 {
+		status := air.awsIAMProvision.Status.DeepCopy()
+		air.dryRun = true
+		pendingActions, _, err := rm.applyACKResources(air, policies, roles)
+		air.dryRun = false
+		air.awsIAMProvision.Status = *status
+		if err != nil {
+			return fail(err)
+		}
+
+		if !rm.acceptObservedPlan(air, pendingActions, documentsHash) {
+			return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
+		}
+	}
+
+	status := air.awsIAMProvision.Status.DeepCopy()
+	pendingActions, notSynced, err := rm.applyACKResources(air, policies, roles)
+
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := rm.syncServiceAccounts(air, false); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return fail(err)
+	}
+
+	air.awsIAMProvision.Status.PendingActions = pendingActions
+	air.awsIAMProvision.Status.PlanHash = ""
+	if air.dryRun {
+		air.awsIAMProvision.Status.PlanHash = planHash(pendingActions, documentsHash)
+	}
+
+	crdPhase := provisionPhase
+	msg := "ACK resources are synced with AWS IAM."
+	syncedStatus, syncedReason := metav1.ConditionTrue, iamv1alpha1.ConditionReasonSynced
+	switch {
+	case air.dryRun:
+		crdPhase = observePhase
+		msg = "ACK resources are in sync with the spec, no actions are pending."
+		if len(air.awsIAMProvision.Status.PendingActions) > 0 {
+			msg = fmt.Sprintf("%d actions are pending, they are applied in the Enforce mode.",
+				len(air.awsIAMProvision.Status.PendingActions))
+			syncedStatus, syncedReason = metav1.ConditionFalse, iamv1alpha1.ConditionReasonPendingActions
+		}
+	case len(notSynced) > 0:
+		crdPhase = provisionIntermediatePhase
+		msg = fmt.Sprintf("ACK resources are not synced with AWS IAM yet: [%s].", strings.Join(notSynced, ", "))
+		syncedStatus, syncedReason = metav1.ConditionFalse, iamv1alpha1.ConditionReasonACKNotSynced
+	}
+
+	if !air.dryRun {
+		managedRoles, managedPolicies := ackManagedResources(&air.awsIAMProvision.Status)
+		if syncedStatus == metav1.ConditionTrue {
+			rm.recordSyncMetrics(air, managedRoles, managedPolicies)
+		} else {
+			rm.recordManagedResources(air, managedRoles, managedPolicies)
+		}
+	}
+
+	rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, syncedStatus, syncedReason, msg)
+	rm.setReadyCondition(air)
+	if syncedStatus == metav1.ConditionTrue {
+		air.awsIAMProvision.Status.ObservedGeneration = air.awsIAMProvision.Generation
+		air.awsIAMProvision.Status.PolicyTemplates = policyTemplatesStatus(air)
+		air.awsIAMProvision.Status.Addons = addonsStatus(air)
+	}
+
+	if air.awsIAMProvision.Status.LastUpdatedTime == nil || status.Phase != crdPhase ||
+		status.ObservedGeneration != air.awsIAMProvision.Status.ObservedGeneration ||
+		status.PlanHash != air.awsIAMProvision.Status.PlanHash ||
+		!equality.Semantic.DeepEqual(status.Conditions, air.awsIAMProvision.Status.Conditions) ||
+		!equality.Semantic.DeepEqual(status.PendingActions, air.awsIAMProvision.Status.PendingActions) ||
+		!equality.Semantic.DeepEqual(status.PolicyTemplates, air.awsIAMProvision.Status.PolicyTemplates) ||
+		!equality.Semantic.DeepEqual(status.Addons, air.awsIAMProvision.Status.Addons) ||
+		!equality.Semantic.DeepEqual(status.Policies, air.awsIAMProvision.Status.Policies) ||
+		!equality.Semantic.DeepEqual(status.Roles, air.awsIAMProvision.Status.Roles) {
+		rm.updateCRDStatus(air, crdPhase, "", msg, nil)
+	}
+
+	return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
+}
+
+// applyACKResources applies the ACK CRs of the roles and policies and deletes the stale ones, the status
+// of the roles and policies mirrors the ACK CRs. It returns the actions recorded in the Observe mode
+// and the names of the ACK CRs which are not synced with AWS IAM yet.
+func (rm *ReconciliationManager) applyACKResources(air *awsIAMResources, policies []*ackiamv1alpha1.Policy,
+	roles []*ackiamv1alpha1.Role) ([]iamv1alpha1.AWSIAMProvisionPendingAction, []string, error) {
+	var (
+		errs           []error
+		pendingActions []iamv1alpha1.AWSIAMProvisionPendingAction
+		notSynced      []string
+	)
+
+	air.awsIAMProvision.Status.Policies = nil
+	air.awsIAMProvision.Status.Roles = nil
+
+	// The policies are applied first, so they can be referenced by the roles.
+	for _, policy := range policies {
+		spec := policy.Spec
+		action, err := rm.applyACKResource(air, policy, func(existing client.Object) bool {
+			existingPolicy := existing.(*ackiamv1alpha1.Policy)
+			changed := !equality.Semantic.DeepEqual(existingPolicy.Spec, spec)
+			existingPolicy.Spec = spec
+
+			return changed
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", *spec.Name, err))
+			continue
+		}
+
+		rm.reportACKAction(air, action, aws_sdk.ResourceTypePolicy, *spec.Name, &pendingActions)
+
+		synced, message := ackSynced(policy.Status.Conditions)
+		if !synced {
+			notSynced = append(notSynced, *spec.Name)
+		}
+
+		air.awsIAMProvision.Status.Policies = append(air.awsIAMProvision.Status.Policies,
+			iamv1alpha1.AWSIAMProvisionStatusPolicy{
+				Conditions: newACKResourceConditions(policy.Status.Conditions, air.awsIAMProvision.Generation),
+				Name:       spec.Name,
+				Message:    message,
+				Phase:      ackPhase(synced),
+				Status: iamv1alpha1.PolicyStatus{
+					AWSIAMResourceMetadata: newACKResourceMetadata(policy.Status.ACKResourceMetadata),
+					AttachmentCount:        int64ToInt32(policy.Status.AttachmentCount),
+					CreateDate:             policy.Status.CreateDate,
+					DefaultVersionID:       policy.Status.DefaultVersionID,
+					PolicyID:               policy.Status.PolicyID,
+				},
+			})
+	}
+
+	for _, role := range roles {
+		spec := role.Spec
+		action, err := rm.applyACKResource(air, role, func(existing client.Object) bool {
+			existingRole := existing.(*ackiamv1alpha1.Role)
+			// The maximum session duration is late initialized by ACK.
+			if spec.MaxSessionDuration == nil {
+				spec.MaxSessionDuration = existingRole.Spec.MaxSessionDuration
+			}
+
+			changed := !equality.Semantic.DeepEqual(existingRole.Spec, spec)
+			existingRole.Spec = spec
+
+			return changed
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("role %s: %w", *spec.Name, err))
+			continue
+		}
+
+		rm.reportACKAction(air, action, aws_sdk.ResourceTypeRole, *spec.Name, &pendingActions)
+
+		synced, message := ackSynced(role.Status.Conditions)
+		if !synced {
+			notSynced = append(notSynced, *spec.Name)
+		}
+
+		air.awsIAMProvision.Status.Roles = append(air.awsIAMProvision.Status.Roles,
+			iamv1alpha1.AWSIAMProvisionStatusRole{
+				Conditions: newACKResourceConditions(role.Status.Conditions, air.awsIAMProvision.Generation),
+				Name:       spec.Name,
+				Message:    message,
+				Phase:      ackPhase(synced),
+				Status: iamv1alpha1.RoleStatus{
+					AWSIAMResourceMetadata: newACKResourceMetadata(role.Status.ACKResourceMetadata),
+					CreateDate:             role.Status.CreateDate,
+					RoleID:                 role.Status.RoleID,
+				},
+			})
+	}
+
+	staleActions, err := rm.deleteStaleACKResources(air, policies, roles)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return append(pendingActions, staleActions...), notSynced, utilerrors.NewAggregate(errs)
+}
+
+// ackManagedResources returns the number of the roles and policies of the ACK CRs which exist in AWS IAM,
+// the ARN of a resource is set by ACK once the resource is created or adopted.
+func ackManagedResources(status *iamv1alpha1.AWSIAMProvisionStatus) (int, int) {
+	var roles, policies int
+	for _, role := range status.Roles {
+		if role.Status.AWSIAMResourceMetadata != nil && role.Status.AWSIAMResourceMetadata.ARN != nil {
+			roles++
+		}
+	}
+
+	for _, policy := range status.Policies {
+		if policy.Status.AWSIAMResourceMetadata != nil && policy.Status.AWSIAMResourceMetadata.ARN != nil {
+			policies++
+		}
+	}
+
+	return roles, policies
+}
+
+// ackDocumentsHash returns the hash of the desired specs of the ACK CRs, the documents of the roles and policies
+// are rendered into them.
+func ackDocumentsHash(policies []*ackiamv1alpha1.Policy, roles []*ackiamv1alpha1.Role) (string, error) {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, policy := range policies {
+		if err := encoder.Encode(policy.Spec); err != nil {
+			return "", err
+		}
+	}
+
+	for _, role := range roles {
+		if err := encoder.Encode(role.Spec); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
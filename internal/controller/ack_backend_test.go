@@ -0,0 +1,248 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
+)
+
+// TestReconcileACKBackend emits the ACK Role and Policy CRs, mirrors their state and deletes the stale ones.
+func TestReconcileACKBackend(t *testing.T) {
+	const clusterName = "ack"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[1].(*iamv1alpha1.AWSIAMProvision).Spec.Backend = iamv1alpha1.BackendACK
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+
+	reconcileACK := func() *iamv1alpha1.AWSIAMProvision {
+		mustReconcile(t, r, key)
+
+		air := &iamv1alpha1.AWSIAMProvision{}
+		mustGet(t, r, key, air)
+
+		return air
+	}
+
+	air := reconcileACK()
+	if len(iamManager.roles) > 0 || len(iamManager.policies) > 0 {
+		t.Fatalf("AWS IAM mutated by the ACK backend: roles %v, policies %v", iamManager.roles, iamManager.policies)
+	}
+
+	if condition := meta.FindStatusCondition(air.Status.Conditions, iamv1alpha1.ConditionTypeSynced); condition == nil ||
+		condition.Reason != iamv1alpha1.ConditionReasonACKNotSynced {
+		t.Errorf("unexpected Synced condition of the ACK resources which are not synced: %+v", condition)
+	}
+
+	// The ACK CRs are not created in AWS IAM yet.
+	if roles, policies := managedResources(air, metrics.ResourceTypeRole),
+		managedResources(air, metrics.ResourceTypePolicy); roles != 0 || policies != 0 {
+		t.Errorf("ACK resources not created in AWS IAM are counted: roles %v, policies %v", roles, policies)
+	}
+
+	role := &ackiamv1alpha1.Role{}
+	if err := r.Get(ctx, testKey("ack-role"), role); err != nil {
+		t.Fatal(err)
+	}
+
+	if !metav1.IsControlledBy(role, air) || len(role.Spec.PolicyRefs) != 1 ||
+		aws.ToString(role.Spec.PolicyRefs[0].From.Name) != "ack-policy" ||
+		!strings.Contains(*role.Spec.AssumeRolePolicyDocument, oidcProviderARN(clusterName)) {
+		t.Errorf("unexpected ACK role: %+v", role)
+	}
+
+	// The name of the CR may exceed the limit of the label values, so the ACK CRs are labeled by its UID.
+	if role.Labels[ackOwnerLabel] != string(air.UID) || role.Annotations[ackOwnerNameAnnotation] != air.Name {
+		t.Errorf("unexpected owner labels %v and annotations %v of the ACK role", role.Labels, role.Annotations)
+	}
+
+	// The ACK iam-controller syncs the resources.
+	policy := &ackiamv1alpha1.Policy{}
+	if err := r.Get(ctx, testKey("ack-policy"), policy); err != nil {
+		t.Fatal(err)
+	}
+
+	synced := []*ackv1alpha1.Condition{{Type: ackv1alpha1.ConditionTypeResourceSynced, Status: corev1.ConditionTrue}}
+	for _, object := range []client.Object{policy, role} {
+		arn := ackv1alpha1.AWSResourceName("arn:aws:iam::" + testAccountID + ":" + object.GetName())
+		metadata := &ackv1alpha1.ResourceMetadata{ARN: &arn}
+		switch obj := object.(type) {
+		case *ackiamv1alpha1.Policy:
+			obj.Status.ACKResourceMetadata, obj.Status.Conditions = metadata, synced
+		case *ackiamv1alpha1.Role:
+			obj.Status.ACKResourceMetadata, obj.Status.Conditions = metadata, synced
+		}
+
+		if err := r.Update(ctx, object); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readerRole := &ackiamv1alpha1.Role{}
+	if err := r.Get(ctx, testKey("ack-reader"), readerRole); err != nil {
+		t.Fatal(err)
+	}
+
+	readerRole.Status.Conditions = synced
+	if err := r.Update(ctx, readerRole); err != nil {
+		t.Fatal(err)
+	}
+
+	air = reconcileACK()
+	if !meta.IsStatusConditionTrue(air.Status.Conditions, iamv1alpha1.ConditionTypeReady) {
+		t.Errorf("CR is not ready: %+v", air.Status.Conditions)
+	}
+
+	// The reader role is synced by ACK without the ARN, so it is not counted.
+	if roles, policies := managedResources(air, metrics.ResourceTypeRole),
+		managedResources(air, metrics.ResourceTypePolicy); roles != 1 || policies != 1 {
+		t.Errorf("expected 1 role and 1 policy, got roles %v, policies %v", roles, policies)
+	}
+
+	if testutil.ToFloat64(metrics.LastSuccessfulSync.WithLabelValues(air.Namespace, air.Name)) == 0 {
+		t.Error("last successful sync of the ACK backend not recorded")
+	}
+
+	if len(air.Status.Policies) != 1 || air.Status.Policies[0].Status.AWSIAMResourceMetadata == nil ||
+		string(*air.Status.Policies[0].Status.AWSIAMResourceMetadata.ARN) != "arn:aws:iam::"+testAccountID+":ack-policy" {
+		t.Errorf("ARN of the ACK policy is not mirrored: %+v", air.Status.Policies)
+	}
+
+	// The role removed from the spec is deleted by ACK.
+	delete(air.Spec.Roles, "reader")
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileACK()
+	err := r.Get(ctx, testKey("ack-reader"), &ackiamv1alpha1.Role{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("stale ACK role is not deleted: %v", err)
+	}
+}
+
+// TestReconcileACKObservedPlan checks the ACK backend applies the plan observed in the Observe mode only if
+// the recomputed plan is the same.
+func TestReconcileACKObservedPlan(t *testing.T) {
+	const clusterName = "ack"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	air.Spec.Backend = iamv1alpha1.BackendACK
+	air.Spec.Mode = iamv1alpha1.ModeObserve
+
+	r := newTestReconciler(t, newFakeIAMManager(), objects...)
+	mustReconcile(t, r, key)
+
+	mustGet(t, r, key, air)
+	if len(air.Status.PlanHash) == 0 || len(air.Status.PendingActions) != 3 {
+		t.Fatalf("unexpected observed plan %s: %+v", air.Status.PlanHash, air.Status.PendingActions)
+	}
+
+	// The document changed together with the mode, so the recomputed plan is not the observed one.
+	air.Spec.Mode = iamv1alpha1.ModeEnforce
+	policy := air.Spec.Policies["policy"]
+	policy.Spec.PolicyDocument = aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`)
+	air.Spec.Policies["policy"] = policy
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if err := r.Get(ctx, testKey("ack-policy"), &ackiamv1alpha1.Policy{}); !k8serrors.IsNotFound(err) {
+		t.Fatalf("changed plan applied: %v", err)
+	}
+
+	mustGet(t, r, key, air)
+	if condition := meta.FindStatusCondition(air.Status.Conditions, iamv1alpha1.ConditionTypeSynced); condition == nil ||
+		condition.Reason != iamv1alpha1.ConditionReasonPlanChanged {
+		t.Errorf("expected Synced condition with %s reason, got %+v", iamv1alpha1.ConditionReasonPlanChanged, condition)
+	}
+
+	// The new plan is observed and applied as is.
+	for _, mode := range []string{iamv1alpha1.ModeObserve, iamv1alpha1.ModeEnforce} {
+		air.Spec.Mode = mode
+		if err := r.Update(ctx, air); err != nil {
+			t.Fatal(err)
+		}
+
+		mustReconcile(t, r, key)
+		mustGet(t, r, key, air)
+	}
+
+	if err := r.Get(ctx, testKey("ack-policy"), &ackiamv1alpha1.Policy{}); err != nil {
+		t.Errorf("observed plan not applied: %v", err)
+	}
+
+	if len(air.Status.PlanHash) > 0 || len(air.Status.PendingActions) > 0 {
+		t.Errorf("applied plan not cleared: %s %+v", air.Status.PlanHash, air.Status.PendingActions)
+	}
+}
+
+// TestNewACKResourceConditions checks the free-form reasons of the ACK conditions are valid condition reasons.
+func TestNewACKResourceConditions(t *testing.T) {
+	reasonPattern := regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+	for _, test := range []struct {
+		conditionType ackv1alpha1.ConditionType
+		reason        string
+		expected      string
+	}{
+		{conditionType: ackv1alpha1.ConditionTypeResourceSynced, reason: "", expected: "ResourceSynced"},
+		{conditionType: ackv1alpha1.ConditionTypeResourceSynced, reason: "Synced", expected: "Synced"},
+		{conditionType: ackv1alpha1.ConditionTypeTerminal, reason: "access denied: iam:CreateRole",
+			expected: "AccessDeniedIamCreateRole"},
+		{conditionType: ackv1alpha1.ConditionTypeRecoverable, reason: "404 not_found (retry)", expected: "Not_foundRetry"},
+		{conditionType: ackv1alpha1.ConditionTypeAdvisory, reason: "!!! ---", expected: iamv1alpha1.ConditionReasonACKCondition},
+		{conditionType: ackv1alpha1.ConditionTypeAdvisory, reason: "Ошибка", expected: iamv1alpha1.ConditionReasonACKCondition},
+	} {
+		conditions := newACKResourceConditions([]*ackv1alpha1.Condition{{
+			Type:   test.conditionType,
+			Status: corev1.ConditionFalse,
+			Reason: aws.String(test.reason),
+		}}, 1)
+		if len(conditions) != 1 {
+			t.Fatalf("%q: unexpected conditions %v", test.reason, conditions)
+		}
+
+		if reason := conditions[0].Reason; reason != test.expected || !reasonPattern.MatchString(reason) {
+			t.Errorf("%q: expected reason %s, got %s", test.reason, test.expected, reason)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/addons"
+)
+
+// addonKeyPrefix - the prefix of the keys of the roles and policies of the add-ons in the resolved spec.
+const addonKeyPrefix = "addon-"
+
+// expandAddons adds the role and the policy of every add-on of the spec to the resolved spec before the names
+// are rendered. The add-on name is prefixed by the cluster name unless the names are rendered by the naming scheme.
+// The policy of the add-on refers to the document of the bundle as to an IAMPolicyTemplate.
+func (rm *ReconciliationManager) expandAddons(air *awsIAMResources, namesRendered bool) error {
+	air.addons = nil
+	if len(air.spec.Addons) == 0 {
+		return nil
+	}
+
+	if air.spec.Policies == nil {
+		air.spec.Policies = make(map[string]iamv1alpha1.AWSIAMProvisionPolicy)
+	}
+
+	if air.spec.Roles == nil {
+		air.spec.Roles = make(map[string]iamv1alpha1.AWSIAMProvisionRole)
+	}
+
+	for _, addon := range air.spec.Addons {
+		bundle, err := addons.Get(addon.Name, addon.Version)
+		if err != nil {
+			return fmt.Errorf("addons of %s AWSIAMProvision malformed: %w", rm.request.NamespacedName, err)
+		}
+
+		serviceAccount := addon.ServiceAccount
+		if len(serviceAccount) == 0 {
+			serviceAccount = bundle.ServiceAccount
+		}
+
+		namespace, saName, ok := strings.Cut(serviceAccount, "/")
+		if !ok {
+			return fmt.Errorf("ServiceAccount %s of add-on %s of %s AWSIAMProvision malformed",
+				serviceAccount, addon.Name, rm.request.NamespacedName)
+		}
+
+		key := addonKeyPrefix + addon.Name
+		if _, ok := air.spec.Policies[key]; ok {
+			return fmt.Errorf("policy %s of %s AWSIAMProvision conflicts with add-on %s", key, rm.request.NamespacedName, addon.Name)
+		}
+
+		if _, ok := air.spec.Roles[key]; ok {
+			return fmt.Errorf("role %s of %s AWSIAMProvision conflicts with add-on %s", key, rm.request.NamespacedName, addon.Name)
+		}
+
+		// The IAMPolicyTemplates are cluster-scoped objects, their names can not contain slashes.
+		templateName := fmt.Sprintf("addon/%s/%s", bundle.Name, bundle.Version)
+		air.policyTemplates[templateName] = &iamv1alpha1.IAMPolicyTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: templateName},
+			Spec:       bundle.Template,
+		}
+
+		name := addon.Name
+		if !namesRendered {
+			name = air.spec.EKSClusterName + "-" + addon.Name
+		}
+
+		air.spec.Policies[key] = iamv1alpha1.AWSIAMProvisionPolicy{Spec: iamv1alpha1.PolicySpec{
+			Name:        &name,
+			TemplateRef: &iamv1alpha1.PolicyTemplateReference{Name: templateName, Parameters: addon.Parameters},
+		}}
+
+		air.spec.Roles[key] = iamv1alpha1.AWSIAMProvisionRole{Spec: iamv1alpha1.RoleSpec{
+			Name:     &name,
+			Policies: []*string{&name},
+			Trust: &iamv1alpha1.RoleTrust{ServiceAccounts: []iamv1alpha1.ServiceAccountReference{
+				{Name: saName, Namespace: namespace}}},
+		}}
+
+		air.addons = append(air.addons, iamv1alpha1.AWSIAMProvisionStatusAddon{
+			Name:    addon.Name,
+			Version: bundle.Version,
+		})
+	}
+
+	return nil
+}
+
+// addonsStatus returns the applied versions of the add-ons with the resolved names of their roles.
+func addonsStatus(air *awsIAMResources) []iamv1alpha1.AWSIAMProvisionStatusAddon {
+	var status []iamv1alpha1.AWSIAMProvisionStatusAddon
+	for _, addon := range air.addons {
+		if role, ok := air.spec.Roles[addonKeyPrefix+addon.Name]; ok && role.Spec.Name != nil {
+			addon.Role = *role.Spec.Name
+		}
+
+		status = append(status, addon)
+	}
+
+	return status
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/addons"
+)
+
+// TestReconcileAddons provisions the role and policy bundles of all the add-ons of the catalog.
+func TestReconcileAddons(t *testing.T) {
+	const clusterName = "addons"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	for _, name := range addons.Names() {
+		addon := iamv1alpha1.AddonReference{Name: name}
+		if name == "karpenter" {
+			addon.Parameters = map[string]string{"nodeRoleName": "KarpenterNodeRole-" + clusterName}
+		}
+
+		air.Spec.Addons = append(air.Spec.Addons, addon)
+	}
+
+	air.Spec.Addons[0].ServiceAccount = "custom/custom"
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+	mustReconcile(t, r, key)
+
+	for num, name := range addons.Names() {
+		iamName := clusterName + "-" + name
+		if document, ok := iamManager.documents[iamName]; !ok || !json.Valid([]byte(document)) {
+			t.Errorf("%s: policy document is not provisioned or malformed: %s", name, document)
+		}
+
+		serviceAccount := "system:serviceaccount:custom:custom"
+		if num > 0 {
+			bundle, _ := addons.Get(name, "")
+			serviceAccount = "system:serviceaccount:" + strings.Replace(bundle.ServiceAccount, "/", ":", 1)
+		}
+
+		role, ok := iamManager.roles[iamName]
+		if !ok || !strings.Contains(aws.ToString(role.AssumeRolePolicyDocument), serviceAccount) {
+			t.Errorf("%s: role is not provisioned or does not trust %s", name, serviceAccount)
+		}
+
+		if _, ok := iamManager.attached[iamName][iamName]; !ok {
+			t.Errorf("%s: policy is not attached to the role", name)
+		}
+	}
+
+	mustGet(t, r, key, air)
+
+	if len(air.Status.Addons) != len(addons.Names()) || air.Status.Addons[0].Version != "v1" ||
+		air.Status.Addons[0].Role != clusterName+"-"+air.Status.Addons[0].Name {
+		t.Errorf("unexpected addons status %+v", air.Status.Addons)
+	}
+
+	air.Spec.Addons = []iamv1alpha1.AddonReference{{Name: "ebs-csi", Version: "v0"}}
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Error("expected the error of the unknown version of the add-on")
+	}
+}
@@ -19,29 +19,81 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
 	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
 )
 
-const (
-	frequency = time.Second * 10
-)
+// eksClusterNameField - field index of AWSIAMProvision by the name of the AWSManagedControlPlane.
+const eksClusterNameField = ".spec.eksClusterName"
 
 // AWSIAMProvisionReconciler reconciles a AWSIAMProvision object
 type AWSIAMProvisionReconciler struct {
-	*ReconciliationManager
+	client.Client
+	// APIReader - reads the ConfigMaps and Secrets bypassing the cache, they are watched by their metadata only,
+	// the client by default.
+	APIReader client.Reader
+	// Backend - operator level backend of the roles and policies, IAM or ACK, can be overridden per CR.
+	// The ACK Role and Policy CRs are watched only if it is ACK.
+	Backend string
+	// ClusterSources - the sources of the EKS clusters by the types of spec.clusterSource,
+	// the default sources are used for the missing types.
+	ClusterSources map[string]ClusterSource
+	// IAMNameTemplate - operator level naming scheme of the IAM roles and policies, can be overridden per CR.
+	IAMNameTemplate string
+	// IAMPathPrefix - operator level IAM path of the roles and policies, can be overridden per CR.
+	IAMPathPrefix string
+	// IAMWorkers - number of workers syncing the roles and policies of a CR in parallel, 1 by default.
+	IAMWorkers int
+	// Mode - operator level mode of the reconciliation, Enforce or Observe, can be overridden per CR.
+	Mode string
+	// MaxConcurrentReconciles - maximum number of AWSIAMProvision reconciled in parallel, 1 by default.
+	MaxConcurrentReconciles int
+	// NewIAMClient - creates the AWS IAM client of every reconciliation, NewIAMClient by default.
+	NewIAMClient IAMClientFactory
+	// NewWorkloadClient - creates the client of the workload cluster binding the ServiceAccounts of the roles,
+	// NewWorkloadClient by default.
+	NewWorkloadClient WorkloadClientFactory
+	// Recorder - emits Kubernetes events for every mutation of AWS IAM resources.
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+	// workloadClients - the clients of the workload clusters shared by the reconciliations.
+	workloadClients workloadClientCache
 }
 
 // +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisions/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisions/finalizers,verbs=update
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=iampolicytemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iam.services.k8s.aws,resources=roles;policies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=awsmanagedcontrolplanes,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -49,59 +101,118 @@ type AWSIAMProvisionReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.1/pkg/reconcile
 func (r *AWSIAMProvisionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	r.ctx = ctx
-	r.logger = log.FromContext(ctx)
-	r.request = req
+	rm := &ReconciliationManager{
+		AWSIAMProvisionReconciler: r,
+		ctx:                       ctx,
+		logger:                    log.FromContext(ctx),
+		request:                   req,
+	}
+
+	return rm.reconcile()
+}
 
-	air, err := r.getClusterResources()
+// reconcile handles the request of the ReconciliationManager, the reconciliation state is never shared between requests.
+func (rm *ReconciliationManager) reconcile() (_ ctrl.Result, reconcileErr error) {
+	air, requeueAfter, err := rm.getClusterResources()
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
 	if air == nil {
-		// Resources not ready, re-queuing
-		return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
+		// Resources not ready, the reconciliation is triggered by the changes of the AWSManagedControlPlane
+		// or requeued for the clusters of other sources.
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
-	r.IAMClient, err = aws_sdk.NewIAMClient(air.awsIAMProvision.Spec.Region, r.logger)
-	if err != nil {
-		if err := r.updateCRDStatus(air, failPhase, "", err.Error(), nil); err != nil {
-			return ctrl.Result{}, err
+	// The status accumulated during the reconciliation is written once.
+	defer func() {
+		if err := rm.writeCRDStatus(air); err != nil && reconcileErr == nil {
+			reconcileErr = err
 		}
+	}()
+
+	if rm.getBackend(air) == iamv1alpha1.BackendACK {
+		return rm.reconcileACK(air)
+	}
+
+	newIAMClient := rm.NewIAMClient
+	if newIAMClient == nil {
+		newIAMClient = NewIAMClient
+	}
+
+	rm.IAMClient, err = newIAMClient(rm.ctx, air.awsIAMProvision.Spec.Region, rm.getIAMPathPrefix(air), rm.logger)
+	if err != nil {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeCredentialsValid, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonCredentialsInvalid, err.Error())
+		rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
 
 		return ctrl.Result{}, err
 	}
 
+	rm.setCondition(air, iamv1alpha1.ConditionTypeCredentialsValid, metav1.ConditionTrue,
+		iamv1alpha1.ConditionReasonCredentialsValid, "")
+
+	// In the Observe mode the mutations of AWS IAM resources are recorded as the pending actions.
+	var dryRunIAMClient *aws_sdk.DryRunIAMManager
+	if rm.getMode(air) == iamv1alpha1.ModeObserve {
+		dryRunIAMClient = aws_sdk.NewDryRunIAMManager(rm.IAMClient)
+		rm.IAMClient = dryRunIAMClient
+		air.dryRun = true
+	}
+
 	// examine DeletionTimestamp to determine if object is under deletion
 	if air.awsIAMProvision.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
 		// then lets add the finalizer and update the object. This is equivalent
 		// to registering our finalizer.
 		if !controllerutil.ContainsFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName) {
+			// The update overwrites the status of the object by the remote one, the accumulated status is restored.
+			status := air.awsIAMProvision.Status.DeepCopy()
 			controllerutil.AddFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName)
-			if err := r.Update(r.ctx, air.awsIAMProvision); err != nil {
+			if err := rm.Update(rm.ctx, air.awsIAMProvision); err != nil {
 				return ctrl.Result{}, err
 			}
+
+			air.awsIAMProvision.Status = *status
 		}
 	} else {
 		// The object is being deleted
 		if controllerutil.ContainsFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName) {
-			if err := r.updateCRDStatus(air, destroyIntermediatePhase, "",
-				"Destroying AWS IAM resources.", nil); err != nil {
-				return ctrl.Result{}, err
-			}
+			if air.dryRun {
+				// AWS IAM is never mutated in the Observe mode, the resources are left as is.
+				if err := rm.recordRetainedResources(air); err != nil {
+					rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
 
-			// our finalizer is present, so lets handle any external dependency
-			if err := r.deleteIAMResources(air.awsIAMProvision); err != nil {
-				if err := r.updateCRDStatus(air, failPhase, "", err.Error(), nil); err != nil {
 					return ctrl.Result{}, err
 				}
 
-				return ctrl.Result{}, err
+				rm.updateCRDStatus(air, destroyPhase, "",
+					"AWS IAM resources were not destroyed in the Observe mode.", nil)
+			} else {
+				rm.updateCRDStatus(air, destroyIntermediatePhase, "",
+					"Destroying AWS IAM resources.", nil)
+				if err := rm.writeCRDStatus(air); err != nil {
+					return ctrl.Result{}, err
+				}
+
+				// our finalizer is present, so lets handle any external dependency
+				if err := rm.syncServiceAccounts(air, true); err != nil {
+					rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+
+					return ctrl.Result{}, err
+				}
+
+				if err := rm.deleteIAMResources(air); err != nil {
+					rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+
+					return ctrl.Result{}, err
+				}
+
+				rm.updateCRDStatus(air, destroyPhase, "",
+					"AWS IAM resources was destroyed.", nil)
 			}
 
-			if err := r.updateCRDStatus(air, destroyPhase, "",
-				"AWS IAM resources was destroyed.", nil); err != nil {
+			if err := rm.writeCRDStatus(air); err != nil {
 				return ctrl.Result{}, err
 			}
 
@@ -109,43 +220,262 @@ func (r *AWSIAMProvisionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 			// remove our finalizer from the list and update it.
 			controllerutil.RemoveFinalizer(air.awsIAMProvision, awsIAMProvisionFinalizerName)
-			if err := r.Update(r.ctx, air.awsIAMProvision); err != nil {
+			if err := rm.Update(rm.ctx, air.awsIAMProvision); err != nil {
 				return ctrl.Result{}, err
 			}
+
+			metrics.DeleteCRMetrics(air.awsIAMProvision.Namespace, air.awsIAMProvision.Name)
 		}
 
 		return ctrl.Result{}, nil
 	}
 
-	if err := r.syncAWSIAMResources(air); err != nil {
+	// The plan observed in the Observe mode is applied only if it is still the plan of the CR.
+	accepted, err := rm.checkObservedPlan(air)
+	if err == nil && !accepted {
+		return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
+	}
+
+	if err == nil {
+		err = rm.syncIAMResources(air)
+	}
+
+	if err == nil {
+		err = rm.syncServiceAccounts(air, false)
+	}
+
+	// The roles and policies owned by the CR are listed after the sync for the metrics,
+	// so only the resources which exist in AWS IAM are counted.
+	var (
+		managedRoles    []iamType.Role
+		managedPolicies []iamType.Policy
+	)
+	if err == nil && !air.dryRun {
+		managedRoles, managedPolicies, err = rm.listManagedResources(air)
+	}
+
+	if err != nil {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonSyncFailed, err.Error())
+		rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+
 		return ctrl.Result{}, err
 	}
 
-	for _, role := range air.awsIAMProvision.Spec.Roles {
-		if err := r.syncRole(air, &role); err != nil {
-			return ctrl.Result{}, err
-		}
+	status := air.awsIAMProvision.Status.DeepCopy()
+	air.awsIAMProvision.Status.PendingActions = nil
+	air.awsIAMProvision.Status.PlanHash = ""
+	if dryRunIAMClient != nil {
+		air.awsIAMProvision.Status.PendingActions = newPendingActions(dryRunIAMClient.Actions())
+		air.awsIAMProvision.Status.PlanHash = planHash(air.awsIAMProvision.Status.PendingActions, air.documentsHash)
+	}
 
-		if err := r.syncPoliciesByRoleSpec(air, &role); err != nil {
-			return ctrl.Result{}, err
-		}
+	if len(air.conflictRoles) > 0 || len(air.conflictPolicies) > 0 {
+		msg := fmt.Sprintf("AWS IAM resources owned by another AWSIAMProvision were skipped, roles: [%s], policies: [%s].",
+			strings.Join(sortedKeys(air.conflictRoles), ", "),
+			strings.Join(sortedKeys(air.conflictPolicies), ", "))
+		rm.logger.Info(msg)
+		rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonConflict, msg)
+		rm.updateCRDStatus(air, failPhase, "", msg, nil)
+
+		return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
 	}
 
-	msg := fmt.Sprintf("AWS IAM resources synced with the remote state.")
-	r.logger.Info(msg)
-	if air.awsIAMProvision.Status.LastUpdatedTime == nil || air.awsIAMProvision.Status.Phase == "Failed" {
-		if err := r.updateCRDStatus(air, provisionPhase, "", msg, nil); err != nil {
-			return ctrl.Result{}, err
+	crdPhase := provisionPhase
+	msg := "AWS IAM resources synced with the remote state."
+	syncedStatus, syncedReason := metav1.ConditionTrue, iamv1alpha1.ConditionReasonSynced
+	if air.dryRun {
+		crdPhase = observePhase
+		msg = "AWS IAM resources are in sync with the spec, no actions are pending."
+		if len(air.awsIAMProvision.Status.PendingActions) > 0 {
+			msg = fmt.Sprintf("%d actions are pending, they are applied in the Enforce mode.",
+				len(air.awsIAMProvision.Status.PendingActions))
+			syncedStatus, syncedReason = metav1.ConditionFalse, iamv1alpha1.ConditionReasonPendingActions
 		}
+	} else {
+		rm.recordSyncMetrics(air, len(managedRoles), len(managedPolicies))
+		if !air.drifted {
+			rm.setCondition(air, iamv1alpha1.ConditionTypeDrifted, metav1.ConditionFalse,
+				iamv1alpha1.ConditionReasonNoDrift, "")
+		}
+	}
+
+	rm.logger.Info(msg)
+	rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, syncedStatus, syncedReason, msg)
+	rm.setReadyCondition(air)
+	if syncedStatus == metav1.ConditionTrue {
+		air.awsIAMProvision.Status.ObservedGeneration = air.awsIAMProvision.Generation
+		air.awsIAMProvision.Status.PolicyTemplates = policyTemplatesStatus(air)
+		air.awsIAMProvision.Status.Addons = addonsStatus(air)
+		air.awsIAMProvision.Status.DocumentsHash = air.documentsHash
+	}
+
+	if air.awsIAMProvision.Status.LastUpdatedTime == nil || air.awsIAMProvision.Status.Phase != crdPhase ||
+		status.ObservedGeneration != air.awsIAMProvision.Status.ObservedGeneration ||
+		status.DocumentsHash != air.awsIAMProvision.Status.DocumentsHash ||
+		status.PlanHash != air.awsIAMProvision.Status.PlanHash ||
+		!equality.Semantic.DeepEqual(status.Conditions, air.awsIAMProvision.Status.Conditions) ||
+		!equality.Semantic.DeepEqual(status.PendingActions, air.awsIAMProvision.Status.PendingActions) ||
+		!equality.Semantic.DeepEqual(status.PolicyTemplates, air.awsIAMProvision.Status.PolicyTemplates) ||
+		!equality.Semantic.DeepEqual(status.Addons, air.awsIAMProvision.Status.Addons) {
+		rm.updateCRDStatus(air, crdPhase, "", msg, nil)
 	}
 
 	return ctrl.Result{RequeueAfter: setFrequency(air)}, nil
 }
 
+// recordRetainedResources records a warning with the roles and policies of the CR which are left in AWS IAM
+// when the CR is deleted in the Observe mode.
+func (rm *ReconciliationManager) recordRetainedResources(air *awsIAMResources) error {
+	iamRoles, iamPolicies, err := rm.listManagedResources(air)
+	if err != nil {
+		return err
+	}
+
+	if len(iamRoles) == 0 && len(iamPolicies) == 0 {
+		return nil
+	}
+
+	roles := make([]string, 0, len(iamRoles))
+	for _, iamRole := range iamRoles {
+		roles = append(roles, *iamRole.RoleName)
+	}
+
+	policies := make([]string, 0, len(iamPolicies))
+	for _, iamPolicy := range iamPolicies {
+		policies = append(policies, *iamPolicy.PolicyName)
+	}
+
+	sort.Strings(roles)
+	sort.Strings(policies)
+	rm.recordEvent(air, corev1.EventTypeWarning, eventReasonResourcesRetained,
+		fmt.Sprintf("AWS IAM resources were left behind in the Observe mode, roles: [%s], policies: [%s].",
+			strings.Join(roles, ", "), strings.Join(policies, ", ")))
+
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *AWSIAMProvisionReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&iamv1alpha1.AWSIAMProvision{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		Complete(r)
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &iamv1alpha1.AWSIAMProvision{},
+		eksClusterNameField, eksClusterNameIndexer); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &iamv1alpha1.AWSIAMProvision{},
+		variablesFromConfigMapField, variablesFromIndexer(false)); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &iamv1alpha1.AWSIAMProvision{},
+		variablesFromSecretField, variablesFromIndexer(true)); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &iamv1alpha1.AWSIAMProvision{},
+		policyTemplateRefField, policyTemplateRefIndexer); err != nil {
+		return err
+	}
+
+	capaInstalled, err := isKindInstalled(mgr.GetRESTMapper(), mgr.GetScheme(), &ekscontrolplanev1.AWSManagedControlPlane{})
+	if err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&iamv1alpha1.AWSIAMProvision{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findAWSIAMProvisionsForVariablesSource(variablesFromConfigMapField)),
+			builder.OnlyMetadata).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findAWSIAMProvisionsForVariablesSource(variablesFromSecretField)),
+			builder.OnlyMetadata).
+		Watches(&iamv1alpha1.IAMPolicyTemplate{},
+			handler.EnqueueRequestsFromMapFunc(r.findAWSIAMProvisionsForPolicyTemplate),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles})
+
+	// The CAPA CRDs may be not installed if the clusters are resolved by the other sources.
+	if capaInstalled {
+		b = b.Watches(&ekscontrolplanev1.AWSManagedControlPlane{},
+			handler.EnqueueRequestsFromMapFunc(r.findAWSIAMProvisionsForControlPlane),
+			builder.WithPredicates(controlPlaneChangedPredicate()))
+	} else {
+		mgr.GetLogger().Info("AWSManagedControlPlane CRD not installed, the control planes are not watched")
+	}
+
+	// The ACK CRDs may be not installed, the ACK CRs of the CRs with the ACK backend are polled then.
+	if r.Backend == iamv1alpha1.BackendACK {
+		b = b.Owns(&ackiamv1alpha1.Role{}).Owns(&ackiamv1alpha1.Policy{})
+	}
+
+	return b.Complete(r)
+}
+
+// isKindInstalled checks whether the API server serves the kind of the object,
+// the watch of a kind whose CRD is not installed fails the start of the manager.
+func isKindInstalled(mapper meta.RESTMapper, scheme *runtime.Scheme, obj client.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// eksClusterNameIndexer indexes AWSIAMProvision by the name of its AWSManagedControlPlane.
+func eksClusterNameIndexer(obj client.Object) []string {
+	return []string{obj.(*iamv1alpha1.AWSIAMProvision).Spec.EKSClusterName}
+}
+
+// findAWSIAMProvisionsForControlPlane maps the AWSManagedControlPlane to the AWSIAMProvision CRs of the cluster.
+func (r *AWSIAMProvisionReconciler) findAWSIAMProvisionsForControlPlane(ctx context.Context, obj client.Object) []reconcile.Request {
+	awsIAMProvisions := &iamv1alpha1.AWSIAMProvisionList{}
+	if err := r.List(ctx, awsIAMProvisions, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{eksClusterNameField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list AWSIAMProvision of AWSManagedControlPlane",
+			"awsManagedControlPlane", client.ObjectKeyFromObject(obj))
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(awsIAMProvisions.Items))
+	for _, awsIAMProvision := range awsIAMProvisions.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&awsIAMProvision)})
+	}
+
+	return requests
+}
+
+// controlPlaneChangedPredicate passes the changes of the AWSManagedControlPlane which affect the AWSIAMProvision CRs:
+// creation, deletion, readiness and OIDC provider.
+func controlPlaneChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCP, ok := e.ObjectOld.(*ekscontrolplanev1.AWSManagedControlPlane)
+			if !ok {
+				return false
+			}
+
+			newCP, ok := e.ObjectNew.(*ekscontrolplanev1.AWSManagedControlPlane)
+			if !ok {
+				return false
+			}
+
+			return oldCP.Status.Ready != newCP.Status.Ready ||
+				oldCP.Status.OIDCProvider.ARN != newCP.Status.OIDCProvider.ARN
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
 }
@@ -0,0 +1,461 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
+)
+
+// TestReconcileConcurrently reconciles different CRs in parallel, run it with -race to detect shared state.
+func TestReconcileConcurrently(t *testing.T) {
+	const clusters = 8
+
+	var objects []client.Object
+	for i := 0; i < clusters; i++ {
+		objects = append(objects, newTestObjects(fmt.Sprintf("cluster-%d", i))...)
+	}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+	r.MaxConcurrentReconciles = clusters
+
+	// Every CR is reconciled twice: the first reconciliation provisions the resources, the second one is a resync.
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+		errs := make(chan error, clusters)
+		for i := 0; i < clusters; i++ {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+
+				req := ctrl.Request{NamespacedName: testKey(name)}
+				if _, err := r.Reconcile(context.Background(), req); err != nil {
+					errs <- fmt.Errorf("reconcile of %s: %w", name, err)
+				}
+			}(fmt.Sprintf("cluster-%d", i))
+		}
+
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	}
+
+	for i := 0; i < clusters; i++ {
+		clusterName := fmt.Sprintf("cluster-%d", i)
+		air := &iamv1alpha1.AWSIAMProvision{}
+		if err := r.Get(context.Background(), testKey(clusterName), air); err != nil {
+			t.Fatal(err)
+		}
+
+		if air.Status.Phase != provisionPhase {
+			t.Errorf("%s: expected phase %s, got %s: %s", clusterName, provisionPhase, air.Status.Phase, air.Status.Message)
+		}
+
+		if len(air.Status.Roles) != 2 {
+			t.Errorf("%s: unexpected roles in status: %v", clusterName, air.Status.Roles)
+		}
+
+		for _, roleStatus := range air.Status.Roles {
+			if !strings.HasPrefix(*roleStatus.Name, clusterName+"-") {
+				t.Errorf("%s: role of another CR in status: %s", clusterName, *roleStatus.Name)
+			}
+		}
+
+		if len(air.Status.Policies) != 1 || *air.Status.Policies[0].Name != clusterName+"-policy" {
+			t.Errorf("%s: unexpected policies in status: %v", clusterName, air.Status.Policies)
+		}
+
+		role, exists, _ := iamManager.GetRoleByName(aws.String(clusterName + "-role"))
+		if !exists {
+			t.Fatalf("%s: role not provisioned", clusterName)
+		}
+
+		if !strings.Contains(*role.AssumeRolePolicyDocument, oidcProviderARN(clusterName)) {
+			t.Errorf("%s: trust policy rendered with OIDC provider of another cluster: %s",
+				clusterName, *role.AssumeRolePolicyDocument)
+		}
+
+		owner := &aws_sdk.ResourceOwner{UID: string(air.UID)}
+		if owner.GetOwnership(role.Tags) != aws_sdk.Owned {
+			t.Errorf("%s: role tagged with identity of another CR: %v", clusterName, role.Tags)
+		}
+
+		for _, roleName := range []string{clusterName + "-role", clusterName + "-reader"} {
+			if _, ok := iamManager.attached[roleName][clusterName+"-policy"]; !ok {
+				t.Errorf("%s: policy not attached to role %s", clusterName, roleName)
+			}
+		}
+	}
+}
+
+// TestReconcileObserveMode computes the pending actions without mutating AWS IAM and applies them in the Enforce mode.
+func TestReconcileObserveMode(t *testing.T) {
+	const clusterName = "observed"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[1].(*iamv1alpha1.AWSIAMProvision).Spec.Mode = iamv1alpha1.ModeObserve
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+
+	reconcileWithMode := func(mode string, mutate func(air *iamv1alpha1.AWSIAMProvision)) *iamv1alpha1.AWSIAMProvision {
+		air := &iamv1alpha1.AWSIAMProvision{}
+		mustGet(t, r, key, air)
+
+		air.Spec.Mode = mode
+		if mutate != nil {
+			mutate(air)
+		}
+
+		if err := r.Update(ctx, air); err != nil {
+			t.Fatal(err)
+		}
+
+		mustReconcile(t, r, key)
+
+		mustGet(t, r, key, air)
+
+		return air
+	}
+
+	air := reconcileWithMode(iamv1alpha1.ModeObserve, nil)
+	if len(iamManager.roles) > 0 || len(iamManager.policies) > 0 {
+		t.Fatalf("AWS IAM mutated in the Observe mode: roles %v, policies %v", iamManager.roles, iamManager.policies)
+	}
+
+	expected := []iamv1alpha1.AWSIAMProvisionPendingAction{
+		{Action: aws_sdk.ActionCreate, Name: clusterName + "-policy", ResourceType: aws_sdk.ResourceTypePolicy},
+		{Action: aws_sdk.ActionCreate, Name: clusterName + "-reader", ResourceType: aws_sdk.ResourceTypeRole},
+		{Action: aws_sdk.ActionCreate, Name: clusterName + "-role", ResourceType: aws_sdk.ResourceTypeRole},
+		{Action: aws_sdk.ActionAttach, Name: clusterName + "-policy", ResourceType: aws_sdk.ResourceTypePolicy,
+			Role: clusterName + "-reader"},
+		{Action: aws_sdk.ActionAttach, Name: clusterName + "-policy", ResourceType: aws_sdk.ResourceTypePolicy,
+			Role: clusterName + "-role"},
+	}
+	if !reflect.DeepEqual(air.Status.PendingActions, expected) {
+		t.Errorf("unexpected pending actions: %+v", air.Status.PendingActions)
+	}
+
+	if air.Status.Phase != observePhase {
+		t.Errorf("expected phase %s, got %s: %s", observePhase, air.Status.Phase, air.Status.Message)
+	}
+
+	air = reconcileWithMode(iamv1alpha1.ModeEnforce, nil)
+	if len(air.Status.PendingActions) > 0 {
+		t.Errorf("pending actions left in the Enforce mode: %+v", air.Status.PendingActions)
+	}
+
+	if len(iamManager.roles) != 2 || len(iamManager.policies) != 1 || len(iamManager.attached) != 2 {
+		t.Errorf("pending actions not applied: roles %v, policies %v", iamManager.roles, iamManager.policies)
+	}
+
+	// The recreation of the policy with the new document is planned as a single update.
+	air = reconcileWithMode(iamv1alpha1.ModeObserve, func(air *iamv1alpha1.AWSIAMProvision) {
+		policy := air.Spec.Policies["policy"]
+		policy.Spec.PolicyDocument = aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`)
+		air.Spec.Policies["policy"] = policy
+	})
+	expected = []iamv1alpha1.AWSIAMProvisionPendingAction{
+		{Action: aws_sdk.ActionUpdate, Name: clusterName + "-policy", ResourceType: aws_sdk.ResourceTypePolicy},
+	}
+	if !reflect.DeepEqual(air.Status.PendingActions, expected) {
+		t.Errorf("unexpected pending actions: %+v", air.Status.PendingActions)
+	}
+
+	// The CR deleted in the Observe mode leaves the resources and warns about them.
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	if err := r.Delete(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if err := r.Get(ctx, key, air); !k8serrors.IsNotFound(err) {
+		t.Errorf("finalizer not removed in the Observe mode: %v", err)
+	}
+
+	if len(iamManager.roles) != 2 || len(iamManager.policies) != 1 {
+		t.Errorf("AWS IAM mutated on deletion in the Observe mode: roles %v, policies %v",
+			iamManager.roles, iamManager.policies)
+	}
+
+	retained := fmt.Sprintf("Warning %s AWS IAM resources were left behind in the Observe mode, "+
+		"roles: [%[2]s-reader, %[2]s-role], policies: [%[2]s-policy].", eventReasonResourcesRetained, clusterName)
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+
+	if !slices.Contains(events, retained) {
+		t.Errorf("retained resources event not recorded: %v", events)
+	}
+}
+
+// TestReconcileObservedPlan checks the Enforce mode applies the plan observed in the Observe mode only if
+// the recomputed plan is the same.
+func TestReconcileObservedPlan(t *testing.T) {
+	const clusterName = "planned"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[1].(*iamv1alpha1.AWSIAMProvision).Spec.Mode = iamv1alpha1.ModeObserve
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+	mustReconcile(t, r, key)
+
+	air := &iamv1alpha1.AWSIAMProvision{}
+	mustGet(t, r, key, air)
+	if len(air.Status.PlanHash) == 0 {
+		t.Fatal("plan hash of the observed plan not set")
+	}
+
+	observed := air.Status.DeepCopy()
+
+	// The spec changed together with the mode, so the recomputed plan is not the observed one.
+	air.Spec.Mode = iamv1alpha1.ModeEnforce
+	delete(air.Spec.Roles, "reader")
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if len(iamManager.roles) > 0 || len(iamManager.policies) > 0 {
+		t.Fatalf("changed plan applied: roles %v, policies %v", iamManager.roles, iamManager.policies)
+	}
+
+	mustGet(t, r, key, air)
+	synced := meta.FindStatusCondition(air.Status.Conditions, iamv1alpha1.ConditionTypeSynced)
+	if synced == nil || synced.Status != metav1.ConditionFalse || synced.Reason != iamv1alpha1.ConditionReasonPlanChanged {
+		t.Errorf("expected Synced condition with %s reason, got %+v", iamv1alpha1.ConditionReasonPlanChanged, synced)
+	}
+
+	if air.Status.PlanHash != observed.PlanHash || !reflect.DeepEqual(air.Status.PendingActions, observed.PendingActions) {
+		t.Errorf("observed plan not kept: %+v", air.Status.PendingActions)
+	}
+
+	// The new plan is observed and applied as is.
+	air.Spec.Mode = iamv1alpha1.ModeObserve
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	mustGet(t, r, key, air)
+	if air.Status.PlanHash == observed.PlanHash {
+		t.Error("plan hash of the changed plan not updated")
+	}
+
+	air.Spec.Mode = iamv1alpha1.ModeEnforce
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if len(iamManager.roles) != 1 || len(iamManager.policies) != 1 {
+		t.Errorf("observed plan not applied: roles %v, policies %v", iamManager.roles, iamManager.policies)
+	}
+
+	mustGet(t, r, key, air)
+	if len(air.Status.PlanHash) > 0 || len(air.Status.PendingActions) > 0 {
+		t.Errorf("applied plan not cleared: %s %+v", air.Status.PlanHash, air.Status.PendingActions)
+	}
+
+	// The changes of the spec in the Enforce mode are applied as usual once the plan is applied.
+	mustGet(t, r, key, air)
+	air.Spec.Roles["reader"] = newTestObjects(clusterName)[1].(*iamv1alpha1.AWSIAMProvision).Spec.Roles["reader"]
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if len(iamManager.roles) != 2 {
+		t.Errorf("spec change not applied in the Enforce mode: roles %v", iamManager.roles)
+	}
+}
+
+// TestReconcileManagedResourcesMetric checks the managed resources gauge counts the roles and policies of the CR
+// which exist in AWS IAM rather than the ones declared in the spec.
+func TestReconcileManagedResourcesMetric(t *testing.T) {
+	const clusterName = "metered"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[1].(*iamv1alpha1.AWSIAMProvision).Spec.Mode = iamv1alpha1.ModeObserve
+
+	r := newTestReconciler(t, newFakeIAMManager(), objects...)
+	mustReconcile(t, r, key)
+
+	air := &iamv1alpha1.AWSIAMProvision{}
+	mustGet(t, r, key, air)
+	if roles, policies := managedResources(air, metrics.ResourceTypeRole),
+		managedResources(air, metrics.ResourceTypePolicy); roles != 0 || policies != 0 {
+		t.Errorf("planned resources counted in the Observe mode: roles %v, policies %v", roles, policies)
+	}
+
+	air.Spec.Mode = iamv1alpha1.ModeEnforce
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if roles, policies := managedResources(air, metrics.ResourceTypeRole),
+		managedResources(air, metrics.ResourceTypePolicy); roles != 2 || policies != 1 {
+		t.Errorf("expected 2 roles and 1 policy, got roles %v, policies %v", roles, policies)
+	}
+
+	mustGet(t, r, key, air)
+	delete(air.Spec.Roles, "reader")
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if roles := managedResources(air, metrics.ResourceTypeRole); roles != 1 {
+		t.Errorf("deleted role still counted: roles %v", roles)
+	}
+}
+
+// TestIsKindInstalled checks the watches are gated by the kinds served by the API server.
+func TestIsKindInstalled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := ekscontrolplanev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(ekscontrolplanev1.GroupVersion.WithKind("AWSManagedControlPlane"), meta.RESTScopeNamespace)
+
+	installed, err := isKindInstalled(mapper, scheme, &ekscontrolplanev1.AWSManagedControlPlane{})
+	if err != nil || !installed {
+		t.Fatalf("AWSManagedControlPlane expected installed, got %v, %v", installed, err)
+	}
+
+	installed, err = isKindInstalled(mapper, scheme, &clusterv1.Cluster{})
+	if err != nil || installed {
+		t.Fatalf("Cluster expected not installed, got %v, %v", installed, err)
+	}
+
+	if _, err := isKindInstalled(mapper, scheme, &corev1.Secret{}); err == nil {
+		t.Fatal("kind not registered in the scheme expected to fail")
+	}
+}
+
+// TestControlPlaneChangedPredicate checks only the readiness and the OIDC provider of the control plane
+// trigger the reconciliation of its AWSIAMProvision CRs.
+func TestControlPlaneChangedPredicate(t *testing.T) {
+	newControlPlane := func(ready bool, oidcProviderARN string, labels map[string]string) *ekscontrolplanev1.AWSManagedControlPlane {
+		eksCP := &ekscontrolplanev1.AWSManagedControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-a", Namespace: testNamespace, Labels: labels},
+		}
+		eksCP.Status.Ready = ready
+		eksCP.Status.OIDCProvider.ARN = oidcProviderARN
+
+		return eksCP
+	}
+
+	oldCP := newControlPlane(true, oidcProviderARN("cluster-a"), nil)
+	for name, test := range map[string]struct {
+		newCP    *ekscontrolplanev1.AWSManagedControlPlane
+		expected bool
+	}{
+		"not ready":    {newCP: newControlPlane(false, oidcProviderARN("cluster-a"), nil), expected: true},
+		"OIDC changed": {newCP: newControlPlane(true, oidcProviderARN("cluster-b"), nil), expected: true},
+		"labels only":  {newCP: newControlPlane(true, oidcProviderARN("cluster-a"), map[string]string{"a": "b"})},
+		"unchanged":    {newCP: newControlPlane(true, oidcProviderARN("cluster-a"), nil)},
+	} {
+		if got := controlPlaneChangedPredicate().Update(event.UpdateEvent{ObjectOld: oldCP, ObjectNew: test.newCP}); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, got)
+		}
+	}
+
+	if !controlPlaneChangedPredicate().Create(event.CreateEvent{Object: oldCP}) ||
+		!controlPlaneChangedPredicate().Delete(event.DeleteEvent{Object: oldCP}) {
+		t.Error("creation and deletion of the control plane expected to pass")
+	}
+
+	if controlPlaneChangedPredicate().Generic(event.GenericEvent{Object: oldCP}) {
+		t.Error("generic events expected to be filtered out")
+	}
+}
+
+// TestFindAWSIAMProvisionsForControlPlane checks the control plane is mapped to the CRs of its cluster only.
+func TestFindAWSIAMProvisionsForControlPlane(t *testing.T) {
+	objects := append(newTestObjects("cluster-a"), newTestObjects("cluster-b")...)
+	other := newTestObjects("cluster-a")[1].(*iamv1alpha1.AWSIAMProvision)
+	other.Name = "cluster-a-other"
+	objects = append(objects, other)
+
+	r := newTestReconciler(t, newFakeIAMManager())
+	r.Client = fake.NewClientBuilder().
+		WithScheme(r.Scheme).
+		WithObjects(objects...).
+		WithIndex(&iamv1alpha1.AWSIAMProvision{}, eksClusterNameField, eksClusterNameIndexer).
+		Build()
+
+	requests := r.findAWSIAMProvisionsForControlPlane(context.Background(), objects[0])
+	var names []string
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+
+	slices.Sort(names)
+	if expected := []string{"cluster-a", "cluster-a-other"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected requests %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// AWSIAMProvisionFleetReconciler generates an AWSIAMProvision for every AWSManagedControlPlane
+// matching the selector of the AWSIAMProvisionFleet, the AWSIAMProvision CRs are reconciled
+// by AWSIAMProvisionReconciler with the template data of their clusters.
+type AWSIAMProvisionFleetReconciler struct {
+	client.Client
+	// IAMNameTemplate - the operator-wide naming scheme, generatedNameTemplate is not set if it is configured.
+	IAMNameTemplate string
+	Scheme          *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisionfleets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisionfleets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisionfleets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=awsmanagedcontrolplanes,verbs=get;list;watch
+
+// Reconcile creates, updates and deletes the AWSIAMProvision CRs of the fleet, the AWSIAMProvision of a cluster
+// which stops matching is deleted and its finalizer cleans up the IAM resources.
+// The AWSIAMProvision CRs are owned by the fleet, so they are garbage collected together with it.
+func (r *AWSIAMProvisionFleetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	fleet := &iamv1alpha1.AWSIAMProvisionFleet{}
+	if err := r.Get(ctx, req.NamespacedName, fleet); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !fleet.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&fleet.Spec.ClusterSelector)
+	if err != nil {
+		return ctrl.Result{}, reconcile.TerminalError(fmt.Errorf("cluster selector of %s AWSIAMProvisionFleet malformed: %w",
+			req.NamespacedName, err))
+	}
+
+	eksCPs := &ekscontrolplanev1.AWSManagedControlPlaneList{}
+	if err := r.List(ctx, eksCPs, client.InNamespace(fleet.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	generated := &iamv1alpha1.AWSIAMProvisionList{}
+	if err := r.List(ctx, generated, client.InNamespace(fleet.Namespace),
+		client.MatchingLabels{iamv1alpha1.LabelFleet: fleet.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	matching := make(map[string]struct{}, len(eksCPs.Items))
+	clusters := make([]iamv1alpha1.AWSIAMProvisionFleetStatusCluster, 0, len(eksCPs.Items))
+	for _, eksCP := range eksCPs.Items {
+		if !eksCP.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		awsIAMProvision := &iamv1alpha1.AWSIAMProvision{
+			ObjectMeta: metav1.ObjectMeta{Name: generatedName(fleet.Name, eksCP.Name), Namespace: fleet.Namespace},
+		}
+		labels := map[string]string{iamv1alpha1.LabelFleet: fleet.Name, iamv1alpha1.LabelCluster: eksCP.Name}
+		if err := syncGeneratedAWSIAMProvision(ctx, r.Client, r.Scheme, fleet, awsIAMProvision, labels,
+			generatedSpec(&fleet.Spec.Template, eksCP.Name, r.IAMNameTemplate)); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		matching[awsIAMProvision.Name] = struct{}{}
+		clusters = append(clusters, generatedClusterStatus(eksCP.Name, awsIAMProvision))
+	}
+
+	for _, awsIAMProvision := range generated.Items {
+		if _, ok := matching[awsIAMProvision.Name]; ok || !metav1.IsControlledBy(&awsIAMProvision, fleet) {
+			continue
+		}
+
+		logger.Info("cluster does not match the fleet, deleting its AWSIAMProvision",
+			"awsIAMProvision", awsIAMProvision.Name, "cluster", awsIAMProvision.Spec.EKSClusterName)
+		if err := r.Delete(ctx, &awsIAMProvision); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := iamv1alpha1.AWSIAMProvisionFleetStatus{
+		Clusters:           clusters,
+		ObservedGeneration: fleet.Generation,
+		Ready:              sortGeneratedClusters(clusters),
+		Total:              int32(len(clusters)),
+	}
+
+	if equality.Semantic.DeepEqual(fleet.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	latest := fleet.DeepCopy()
+	latest.Status = status
+
+	return ctrl.Result{}, r.Status().Patch(ctx, latest, client.MergeFrom(fleet))
+}
+
+// SetupWithManager sets up the controller with the Manager, the controller is not started
+// if the AWSManagedControlPlane CRD is not installed.
+func (r *AWSIAMProvisionFleetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	installed, err := isKindInstalled(mgr.GetRESTMapper(), mgr.GetScheme(), &ekscontrolplanev1.AWSManagedControlPlane{})
+	if err != nil {
+		return err
+	}
+
+	if !installed {
+		mgr.GetLogger().Info("AWSManagedControlPlane CRD not installed, the AWSIAMProvisionFleet controller is not started")
+		return nil
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&iamv1alpha1.AWSIAMProvisionFleet{}).
+		Owns(&iamv1alpha1.AWSIAMProvision{}).
+		Watches(&ekscontrolplanev1.AWSManagedControlPlane{},
+			handler.EnqueueRequestsFromMapFunc(r.findFleetsForControlPlane)).
+		Complete(r)
+}
+
+// findFleetsForControlPlane maps the AWSManagedControlPlane to all the fleets of its namespace,
+// the labels may stop matching, so the fleets are not filtered by the selector.
+func (r *AWSIAMProvisionFleetReconciler) findFleetsForControlPlane(ctx context.Context, obj client.Object) []reconcile.Request {
+	fleets := &iamv1alpha1.AWSIAMProvisionFleetList{}
+	if err := r.List(ctx, fleets, client.InNamespace(obj.GetNamespace())); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "unable to list AWSIAMProvisionFleet of AWSManagedControlPlane",
+				"awsManagedControlPlane", client.ObjectKeyFromObject(obj))
+		}
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(fleets.Items))
+	for _, fleet := range fleets.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&fleet)})
+	}
+
+	return requests
+}
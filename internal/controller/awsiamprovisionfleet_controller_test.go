@@ -0,0 +1,141 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// TestReconcileFleet generates the AWSIAMProvision CRs of the matching clusters and deletes the one
+// of the cluster which stops matching together with its IAM resources.
+func TestReconcileFleet(t *testing.T) {
+	ctx := context.Background()
+	labels := map[string]string{"environment": "develop"}
+
+	var objects []client.Object
+	for _, clusterName := range []string{"fleet-a", "fleet-b", "fleet-c"} {
+		eksCP := newTestObjects(clusterName)[0]
+		if clusterName != "fleet-c" {
+			eksCP.SetLabels(labels)
+		}
+
+		objects = append(objects, eksCP)
+	}
+
+	fleet := &iamv1alpha1.AWSIAMProvisionFleet{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform", Namespace: testNamespace, Generation: 1, UID: "platform-uid"},
+		Spec: iamv1alpha1.AWSIAMProvisionFleetSpec{
+			ClusterSelector: metav1.LabelSelector{MatchLabels: labels},
+			Template:        newTestTemplateSpec(),
+		},
+	}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, append(objects, fleet)...)
+	fleetReconciler := &AWSIAMProvisionFleetReconciler{Client: r.Client, Scheme: r.Scheme}
+	fleetKey := client.ObjectKeyFromObject(fleet)
+
+	reconcile := func() {
+		t.Helper()
+
+		reconcileGenerated(t, r, fleetReconciler, fleetKey)
+		mustGet(t, r, fleetKey, fleet)
+	}
+
+	reconcile()
+
+	if fleet.Status.Total != 2 || fleet.Status.Ready != 2 || fleet.Status.Clusters[0].Name != "fleet-a" ||
+		fleet.Status.Clusters[1].AWSIAMProvision != "platform-fleet-b" {
+		t.Errorf("unexpected fleet status %+v", fleet.Status)
+	}
+
+	for _, clusterName := range []string{"fleet-a", "fleet-b"} {
+		if document := iamManager.documents[clusterName+"-reader"]; !strings.Contains(document, "arn:aws:s3:::"+clusterName+"/*") {
+			t.Errorf("%s: policy is not provisioned with the cluster template data: %s", clusterName, document)
+		}
+
+		role, ok := iamManager.roles[clusterName+"-reader"]
+		if !ok || !strings.Contains(aws.ToString(role.AssumeRolePolicyDocument), oidcProviderARN(clusterName)) {
+			t.Errorf("%s: role is not provisioned or does not trust the OIDC provider of the cluster", clusterName)
+		}
+	}
+
+	if _, ok := iamManager.roles["fleet-c-reader"]; ok {
+		t.Error("role of the cluster which does not match is provisioned")
+	}
+
+	eksCP := &ekscontrolplanev1.AWSManagedControlPlane{}
+	if err := r.Get(ctx, testKey("fleet-b"), eksCP); err != nil {
+		t.Fatal(err)
+	}
+
+	eksCP.SetLabels(nil)
+	if err := r.Update(ctx, eksCP); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	if fleet.Status.Total != 1 || fleet.Status.Clusters[0].Name != "fleet-a" {
+		t.Errorf("unexpected fleet status %+v", fleet.Status)
+	}
+
+	err := r.Get(ctx, testKey("platform-fleet-b"), &iamv1alpha1.AWSIAMProvision{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("AWSIAMProvision of the cluster which stops matching is not deleted: %v", err)
+	}
+
+	if _, ok := iamManager.roles["fleet-b-reader"]; ok {
+		t.Error("role of the cluster which stops matching is not deleted")
+	}
+
+	if _, ok := iamManager.roles["fleet-a-reader"]; !ok {
+		t.Error("role of the matching cluster is deleted")
+	}
+
+	eksCP = &ekscontrolplanev1.AWSManagedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet-a", Namespace: testNamespace},
+	}
+	if err := r.Delete(ctx, eksCP); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	if fleet.Status.Total != 0 {
+		t.Errorf("unexpected fleet status %+v", fleet.Status)
+	}
+
+	err = r.Get(ctx, testKey("platform-fleet-a"), &iamv1alpha1.AWSIAMProvision{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("AWSIAMProvision of the deleted cluster is not deleted: %v", err)
+	}
+
+	if len(iamManager.roles) > 0 || len(iamManager.policies) > 0 {
+		t.Errorf("IAM resources of the deleted cluster are not deleted: %v %v", iamManager.roles, iamManager.policies)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// awsIAMProvisionTemplateFinalizerName - the finalizer deleting the AWSIAMProvision CRs of the template,
+// they are controlled by the Clusters, so they are not garbage collected together with the template.
+const awsIAMProvisionTemplateFinalizerName = "awsiamprovisiontemplate.iam.aws.edenlab.io/finalizer"
+
+// AWSIAMProvisionTemplateReconciler instantiates the AWSIAMProvisionTemplate into an AWSIAMProvision
+// for every Cluster API Cluster of its ClusterClass or annotated with its name. The AWSIAMProvision CRs
+// are controlled by the Clusters, so the IAM resources are deleted together with the Clusters.
+type AWSIAMProvisionTemplateReconciler struct {
+	client.Client
+	// IAMNameTemplate - the operator-wide naming scheme, generatedNameTemplate is not set if it is configured.
+	IAMNameTemplate string
+	Scheme          *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisiontemplates,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisiontemplates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisiontemplates/finalizers,verbs=update
+// +kubebuilder:rbac:groups=iam.aws.edenlab.io,resources=awsiamprovisions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters/finalizers,verbs=update
+
+// Reconcile creates, updates and deletes the AWSIAMProvision CRs of the template, the AWSIAMProvision
+// of a Cluster which stops using the template or is being deleted is deleted and its finalizer cleans up
+// the IAM resources.
+func (r *AWSIAMProvisionTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	template := &iamv1alpha1.AWSIAMProvisionTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, template); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	generated := &iamv1alpha1.AWSIAMProvisionList{}
+	if err := r.List(ctx, generated, client.InNamespace(template.Namespace),
+		client.MatchingLabels{iamv1alpha1.LabelTemplate: template.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !template.DeletionTimestamp.IsZero() {
+		for _, awsIAMProvision := range generated.Items {
+			if err := r.Delete(ctx, &awsIAMProvision); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if controllerutil.RemoveFinalizer(template, awsIAMProvisionTemplateFinalizerName) {
+			return ctrl.Result{}, r.Update(ctx, template)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(template, awsIAMProvisionTemplateFinalizerName) {
+		if err := r.Update(ctx, template); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := r.List(ctx, clusterList, client.InNamespace(template.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	matching := make(map[string]struct{}, len(clusterList.Items))
+	var clusters []iamv1alpha1.AWSIAMProvisionFleetStatusCluster
+	for _, cluster := range clusterList.Items {
+		if !cluster.DeletionTimestamp.IsZero() || !templateMatchesCluster(template, &cluster) {
+			continue
+		}
+
+		controlPlaneRef := cluster.Spec.ControlPlaneRef
+		if controlPlaneRef == nil || controlPlaneRef.Kind != "AWSManagedControlPlane" {
+			logger.V(1).Info("Cluster has no AWSManagedControlPlane, skipping", "cluster", cluster.Name)
+			continue
+		}
+
+		awsIAMProvision := &iamv1alpha1.AWSIAMProvision{
+			ObjectMeta: metav1.ObjectMeta{Name: generatedName(template.Name, cluster.Name), Namespace: template.Namespace},
+		}
+		labels := map[string]string{iamv1alpha1.LabelTemplate: template.Name, iamv1alpha1.LabelCluster: cluster.Name}
+		if err := syncGeneratedAWSIAMProvision(ctx, r.Client, r.Scheme, &cluster, awsIAMProvision, labels,
+			generatedSpec(&template.Spec.Template, controlPlaneRef.Name, r.IAMNameTemplate)); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		matching[awsIAMProvision.Name] = struct{}{}
+		clusters = append(clusters, generatedClusterStatus(cluster.Name, awsIAMProvision))
+	}
+
+	for _, awsIAMProvision := range generated.Items {
+		if _, ok := matching[awsIAMProvision.Name]; ok {
+			continue
+		}
+
+		logger.Info("Cluster does not use the template, deleting its AWSIAMProvision",
+			"awsIAMProvision", awsIAMProvision.Name, "cluster", awsIAMProvision.Labels[iamv1alpha1.LabelCluster])
+		if err := r.Delete(ctx, &awsIAMProvision); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := iamv1alpha1.AWSIAMProvisionTemplateStatus{
+		Clusters:           clusters,
+		ObservedGeneration: template.Generation,
+		Ready:              sortGeneratedClusters(clusters),
+		Total:              int32(len(clusters)),
+	}
+
+	if equality.Semantic.DeepEqual(template.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	latest := template.DeepCopy()
+	latest.Status = status
+
+	return ctrl.Result{}, r.Status().Patch(ctx, latest, client.MergeFrom(template))
+}
+
+// templateMatchesCluster checks whether the Cluster uses the ClusterClass of the template
+// or lists the template in its annotation.
+func templateMatchesCluster(template *iamv1alpha1.AWSIAMProvisionTemplate, cluster *clusterv1.Cluster) bool {
+	if len(template.Spec.ClusterClassName) > 0 && cluster.Spec.Topology != nil &&
+		cluster.Spec.Topology.Class == template.Spec.ClusterClassName {
+		return true
+	}
+
+	for _, name := range strings.Split(cluster.Annotations[iamv1alpha1.AnnotationTemplates], ",") {
+		if strings.TrimSpace(name) == template.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager, the controller is not started
+// if the Cluster API CRDs are not installed.
+func (r *AWSIAMProvisionTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	installed, err := isKindInstalled(mgr.GetRESTMapper(), mgr.GetScheme(), &clusterv1.Cluster{})
+	if err != nil {
+		return err
+	}
+
+	if !installed {
+		mgr.GetLogger().Info("Cluster CRD not installed, the AWSIAMProvisionTemplate controller is not started")
+		return nil
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&iamv1alpha1.AWSIAMProvisionTemplate{}).
+		Watches(&iamv1alpha1.AWSIAMProvision{},
+			handler.EnqueueRequestsFromMapFunc(findTemplateOfAWSIAMProvision)).
+		Watches(&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.findTemplatesForCluster)).
+		Complete(r)
+}
+
+// findTemplateOfAWSIAMProvision maps the generated AWSIAMProvision to its template,
+// the AWSIAMProvision is controlled by the Cluster, so it is not watched by Owns.
+func findTemplateOfAWSIAMProvision(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[iamv1alpha1.LabelTemplate]
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
+
+// findTemplatesForCluster maps the Cluster to all the templates of its namespace,
+// the Cluster may stop using a template, so the templates are not filtered.
+func (r *AWSIAMProvisionTemplateReconciler) findTemplatesForCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	templates := &iamv1alpha1.AWSIAMProvisionTemplateList{}
+	if err := r.List(ctx, templates, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list AWSIAMProvisionTemplate of Cluster",
+			"cluster", client.ObjectKeyFromObject(obj))
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(templates.Items))
+	for _, template := range templates.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+	}
+
+	return requests
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// TestReconcileTemplate instantiates the template for the Clusters of its ClusterClass or annotated with its name,
+// the AWSIAMProvision CRs are controlled by the Clusters and deleted together with the template.
+func TestReconcileTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	var objects []client.Object
+	for _, clusterName := range []string{"class", "annotated", "deleted", "other"} {
+		eksCPName := "capi-" + clusterName
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: testNamespace, UID: types.UID(clusterName + "-uid")},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneRef: &corev1.ObjectReference{Kind: "AWSManagedControlPlane", Name: eksCPName},
+				Topology:        &clusterv1.Topology{Class: "other"},
+			},
+		}
+
+		switch clusterName {
+		case "class", "deleted":
+			cluster.Spec.Topology.Class = "eks"
+		case "annotated":
+			cluster.Annotations = map[string]string{iamv1alpha1.AnnotationTemplates: "unrelated, platform"}
+		}
+
+		objects = append(objects, cluster, newTestObjects(eksCPName)[0])
+	}
+
+	template := &iamv1alpha1.AWSIAMProvisionTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform", Namespace: testNamespace, Generation: 1},
+		Spec: iamv1alpha1.AWSIAMProvisionTemplateSpec{
+			ClusterClassName: "eks",
+			Template:         newTestTemplateSpec(),
+		},
+	}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, append(objects, template)...)
+	templateReconciler := &AWSIAMProvisionTemplateReconciler{Client: r.Client, Scheme: r.Scheme}
+	templateKey := client.ObjectKeyFromObject(template)
+
+	reconcile := func() {
+		t.Helper()
+
+		reconcileGenerated(t, r, templateReconciler, templateKey)
+	}
+
+	reconcile()
+
+	mustGet(t, r, templateKey, template)
+
+	if template.Status.Total != 3 || template.Status.Ready != 3 || template.Status.Clusters[0].Name != "annotated" ||
+		template.Status.Clusters[1].AWSIAMProvision != "platform-class" {
+		t.Errorf("unexpected template status %+v", template.Status)
+	}
+
+	for _, clusterName := range []string{"class", "annotated", "deleted"} {
+		air := &iamv1alpha1.AWSIAMProvision{}
+		if err := r.Get(ctx, testKey("platform-"+clusterName), air); err != nil {
+			t.Fatal(err)
+		}
+
+		if owner := metav1.GetControllerOf(air); owner == nil || owner.Kind != "Cluster" || owner.Name != clusterName {
+			t.Errorf("%s: AWSIAMProvision is not controlled by the Cluster: %v", clusterName, air.OwnerReferences)
+		}
+
+		if _, ok := iamManager.roles["capi-"+clusterName+"-reader"]; !ok || air.Spec.EKSClusterName != "capi-"+clusterName {
+			t.Errorf("%s: role is not provisioned for the AWSManagedControlPlane of the Cluster", clusterName)
+		}
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, testKey("annotated"), cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	cluster.Annotations = nil
+	if err := r.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	if _, ok := iamManager.roles["capi-annotated-reader"]; ok {
+		t.Error("role of the Cluster which stops using the template is not deleted")
+	}
+
+	// The AWSManagedControlPlane is deleted together with the Cluster, the finalizer of the CR does not need it.
+	for _, obj := range []client.Object{
+		&clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "deleted", Namespace: testNamespace}},
+		&ekscontrolplanev1.AWSManagedControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "capi-deleted", Namespace: testNamespace}},
+	} {
+		if err := r.Delete(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconcile()
+
+	err := r.Get(ctx, testKey("platform-deleted"), &iamv1alpha1.AWSIAMProvision{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("AWSIAMProvision of the deleted Cluster is not deleted: %v", err)
+	}
+
+	if _, ok := iamManager.roles["capi-deleted-reader"]; ok {
+		t.Error("role of the deleted Cluster is not deleted")
+	}
+
+	if err := r.Delete(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	if err := r.Get(ctx, templateKey, template); !k8serrors.IsNotFound(err) {
+		t.Errorf("template is not deleted: %v", err)
+	}
+
+	if len(iamManager.roles) > 0 {
+		t.Errorf("roles of the template are not deleted: %v", iamManager.roles)
+	}
+}
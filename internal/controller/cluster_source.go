@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	eksType "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// Errors of the ClusterSource, the reconciliation waits until the cluster exists and is ready.
+var (
+	ErrClusterNotFound = errors.New("cluster not found")
+	ErrClusterNotReady = errors.New("cluster not ready")
+)
+
+// clusterError - the error of the cluster state with the message reported to the status of the CR.
+type clusterError struct {
+	err error
+	msg string
+}
+
+func newClusterError(err error, format string, args ...interface{}) error {
+	return &clusterError{err: err, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *clusterError) Error() string {
+	return e.msg
+}
+
+func (e *clusterError) Unwrap() error {
+	return e.err
+}
+
+// Cluster - the EKS cluster of the AWSIAMProvision resolved by the ClusterSource.
+type Cluster struct {
+	// OIDCIssuerURL - the OIDC issuer of the cluster, it is used if OIDCProviderARN is not known.
+	OIDCIssuerURL string
+	// OIDCProviderARN - the ARN of the IAM OIDC provider of the cluster.
+	OIDCProviderARN string
+}
+
+// ClusterSource resolves the EKS cluster of the AWSIAMProvision. ErrClusterNotFound and ErrClusterNotReady
+// are returned while the cluster is not available.
+type ClusterSource interface {
+	GetCluster(ctx context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision) (*Cluster, error)
+}
+
+// CAPAClusterSource resolves the cluster by the AWSManagedControlPlane of Cluster API.
+type CAPAClusterSource struct {
+	client.Reader
+}
+
+func (s *CAPAClusterSource) GetCluster(ctx context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision) (*Cluster, error) {
+	key := types.NamespacedName{Name: awsIAMProvision.Spec.EKSClusterName, Namespace: awsIAMProvision.Namespace}
+	eksCP := &ekscontrolplanev1.AWSManagedControlPlane{}
+	if err := s.Get(ctx, key, eksCP); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, newClusterError(ErrClusterNotFound, "AWSManagedControlPlane of %s AWSIAMProvision not found: %s",
+				client.ObjectKeyFromObject(awsIAMProvision), key)
+		}
+
+		return nil, err
+	}
+
+	return capaCluster(awsIAMProvision, eksCP)
+}
+
+func capaCluster(awsIAMProvision *iamv1alpha1.AWSIAMProvision, eksCP *ekscontrolplanev1.AWSManagedControlPlane) (*Cluster, error) {
+	if !eksCP.Status.Ready {
+		return nil, newClusterError(ErrClusterNotReady, "AWSManagedControlPlane of %s AWSIAMProvision not ready: %s",
+			client.ObjectKeyFromObject(awsIAMProvision), client.ObjectKeyFromObject(eksCP))
+	}
+
+	return &Cluster{OIDCProviderARN: eksCP.Status.OIDCProvider.ARN}, nil
+}
+
+// EKSClusterSource resolves the cluster by the EKS DescribeCluster API.
+type EKSClusterSource struct {
+	// DescribeCluster - aws_sdk.DescribeEKSCluster by default.
+	DescribeCluster func(ctx context.Context, region, name string) (*aws_sdk.EKSCluster, error)
+}
+
+func (s *EKSClusterSource) GetCluster(ctx context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision) (*Cluster, error) {
+	describeCluster := s.DescribeCluster
+	if describeCluster == nil {
+		describeCluster = aws_sdk.DescribeEKSCluster
+	}
+
+	eksCluster, err := describeCluster(ctx, awsIAMProvision.Spec.Region, awsIAMProvision.Spec.EKSClusterName)
+	if err != nil {
+		if errors.Is(err, aws_sdk.ErrEKSClusterNotFound) {
+			return nil, newClusterError(ErrClusterNotFound, "EKS cluster of %s AWSIAMProvision not found: %s",
+				client.ObjectKeyFromObject(awsIAMProvision), awsIAMProvision.Spec.EKSClusterName)
+		}
+
+		return nil, err
+	}
+
+	// The OIDC provider of the updated cluster stays the same.
+	if (eksCluster.Status != string(eksType.ClusterStatusActive) &&
+		eksCluster.Status != string(eksType.ClusterStatusUpdating)) || len(eksCluster.OIDCIssuerURL) == 0 {
+		return nil, newClusterError(ErrClusterNotReady, "EKS cluster of %s AWSIAMProvision not ready: %s is %s",
+			client.ObjectKeyFromObject(awsIAMProvision), awsIAMProvision.Spec.EKSClusterName, eksCluster.Status)
+	}
+
+	return &Cluster{OIDCIssuerURL: eksCluster.OIDCIssuerURL}, nil
+}
+
+// OIDCClusterSource resolves the cluster by the OIDC provider set explicitly in the spec.
+type OIDCClusterSource struct{}
+
+func (s *OIDCClusterSource) GetCluster(_ context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision) (*Cluster, error) {
+	clusterSource := awsIAMProvision.Spec.ClusterSource
+	if clusterSource == nil || clusterSource.OIDC == nil {
+		return nil, fmt.Errorf("OIDC provider of %s AWSIAMProvision not set in spec.clusterSource.oidc",
+			client.ObjectKeyFromObject(awsIAMProvision))
+	}
+
+	return &Cluster{
+		OIDCIssuerURL:   clusterSource.OIDC.IssuerURL,
+		OIDCProviderARN: clusterSource.OIDC.ProviderARN,
+	}, nil
+}
+
+// getClusterSource returns the type and the ClusterSource of the CR, the sources of the reconciler take precedence
+// over the default ones.
+func (rm *ReconciliationManager) getClusterSource(air *awsIAMResources) (string, ClusterSource) {
+	sourceType := iamv1alpha1.ClusterSourceCAPA
+	if air.awsIAMProvision.Spec.ClusterSource != nil {
+		sourceType = air.awsIAMProvision.Spec.ClusterSource.Type
+	}
+
+	if clusterSource, ok := rm.ClusterSources[sourceType]; ok {
+		return sourceType, clusterSource
+	}
+
+	switch sourceType {
+	case iamv1alpha1.ClusterSourceEKS:
+		return sourceType, &EKSClusterSource{}
+	case iamv1alpha1.ClusterSourceOIDC:
+		return sourceType, &OIDCClusterSource{}
+	default:
+		return sourceType, &CAPAClusterSource{Reader: rm.Client}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestReconcileClusterSources resolves the OIDC provider of the clusters without the AWSManagedControlPlane.
+func TestReconcileClusterSources(t *testing.T) {
+	const issuer = "oidc.eks." + testRegion + ".amazonaws.com/id/SOURCE"
+
+	ctx := context.Background()
+	expectedARN := fmt.Sprintf("arn:aws:iam::%s:oidc-provider/%s", testAccountID, issuer)
+
+	eksStatus := "CREATING"
+	describeCluster := func(_ context.Context, region, name string) (*aws_sdk.EKSCluster, error) {
+		if name != "eks" || region != testRegion {
+			return nil, fmt.Errorf("%w: %s", aws_sdk.ErrEKSClusterNotFound, name)
+		}
+
+		return &aws_sdk.EKSCluster{OIDCIssuerURL: "https://" + issuer, Status: eksStatus}, nil
+	}
+
+	for _, tc := range []struct {
+		clusterName   string
+		clusterSource *iamv1alpha1.ClusterSource
+	}{
+		{"oidc-arn", &iamv1alpha1.ClusterSource{Type: iamv1alpha1.ClusterSourceOIDC,
+			OIDC: &iamv1alpha1.OIDCProviderSource{ProviderARN: expectedARN}}},
+		{"oidc-issuer", &iamv1alpha1.ClusterSource{Type: iamv1alpha1.ClusterSourceOIDC,
+			OIDC: &iamv1alpha1.OIDCProviderSource{IssuerURL: "https://" + issuer}}},
+		{"eks", &iamv1alpha1.ClusterSource{Type: iamv1alpha1.ClusterSourceEKS}},
+	} {
+		key := testKey(tc.clusterName)
+		air := newTestObjects(tc.clusterName)[1].(*iamv1alpha1.AWSIAMProvision)
+		air.Spec.ClusterSource = tc.clusterSource
+
+		iamManager := newFakeIAMManager()
+		r := newTestReconciler(t, iamManager, air)
+		r.ClusterSources = map[string]ClusterSource{
+			iamv1alpha1.ClusterSourceEKS: &EKSClusterSource{DescribeCluster: describeCluster},
+		}
+
+		if tc.clusterSource.Type == iamv1alpha1.ClusterSourceEKS {
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.RequeueAfter == 0 || len(iamManager.roles) > 0 {
+				t.Errorf("%s: the cluster which is not ready is not polled: %+v", tc.clusterName, result)
+			}
+
+			eksStatus = "ACTIVE"
+		}
+
+		mustReconcile(t, r, key)
+
+		role, ok := iamManager.roles[tc.clusterName+"-role"]
+		if !ok {
+			t.Fatalf("%s: role is not created", tc.clusterName)
+		}
+
+		if !strings.Contains(*role.AssumeRolePolicyDocument, expectedARN) {
+			t.Errorf("%s: unexpected OIDC provider in %s", tc.clusterName, *role.AssumeRolePolicyDocument)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
+)
+
+const (
+	testAccountID = "123456789012"
+	testNamespace = "default"
+	testRegion    = "eu-north-1"
+)
+
+// fakeIAMManager - in-memory AWS IAM account shared by all reconciliations of a test.
+type fakeIAMManager struct {
+	mu       sync.Mutex
+	attached map[string]map[string]struct{}
+	// calls - the number of the calls by the method names.
+	calls      map[string]int
+	documents  map[string]string
+	policies   map[string]iamType.Policy
+	roles      map[string]iamType.Role
+	pathPrefix string
+}
+
+var _ aws_sdk.IAMManager = &fakeIAMManager{}
+
+func newFakeIAMManager() *fakeIAMManager {
+	return &fakeIAMManager{
+		attached:   make(map[string]map[string]struct{}),
+		calls:      make(map[string]int),
+		documents:  make(map[string]string),
+		policies:   make(map[string]iamType.Policy),
+		roles:      make(map[string]iamType.Role),
+		pathPrefix: aws_sdk.DefaultPathPrefix,
+	}
+}
+
+// managedResources returns the managed resources gauge of the CR by the resource type.
+func managedResources(air *iamv1alpha1.AWSIAMProvision, resourceType string) float64 {
+	return testutil.ToFloat64(metrics.ManagedResources.WithLabelValues(air.Spec.EKSClusterName, air.Namespace, air.Name,
+		resourceType))
+}
+
+func hasTags(resourceTags, tags []iamType.Tag) bool {
+	values := make(map[string]string)
+	for _, tag := range resourceTags {
+		values[*tag.Key] = *tag.Value
+	}
+
+	for _, tag := range tags {
+		if value, ok := values[*tag.Key]; !ok || value != *tag.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mergeTags(resourceTags, tags []iamType.Tag) []iamType.Tag {
+	result := append([]iamType.Tag{}, resourceTags...)
+	for _, tag := range tags {
+		if !hasTags(result, []iamType.Tag{tag}) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+func (f *fakeIAMManager) AttachRolePolicy(policyName, roleName *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.attached[*roleName]; !ok {
+		f.attached[*roleName] = make(map[string]struct{})
+	}
+
+	f.attached[*roleName][*policyName] = struct{}{}
+
+	return nil
+}
+
+func (f *fakeIAMManager) BatchAttachDetachRolePolicies(proc string, policies []iamType.Policy, roleName *string) error {
+	for _, policy := range policies {
+		switch proc {
+		case aws_sdk.ButchAttachProc:
+			if err := f.AttachRolePolicy(policy.PolicyName, roleName); err != nil {
+				return err
+			}
+		case aws_sdk.ButchDetachProc:
+			if err := f.DetachRolePolicy(policy.PolicyName, roleName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeIAMManager) BatchDeletePolicies(policies []iamType.Policy) error {
+	for _, policy := range policies {
+		if err := f.DeletePolicy(policy.PolicyName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeIAMManager) CreatePolicy(policyName, policyData, _ *string, tags []iamType.Tag) (*iamType.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.policies[*policyName]; ok {
+		return nil, nil
+	}
+
+	policy := iamType.Policy{
+		Arn:        aws.String(fmt.Sprintf("arn:aws:iam::%s:policy%s%s", testAccountID, f.pathPrefix, *policyName)),
+		Path:       aws.String(f.pathPrefix),
+		PolicyId:   aws.String("ID" + strings.ToUpper(*policyName)),
+		PolicyName: policyName,
+		Tags:       tags,
+	}
+	f.policies[*policyName] = policy
+	f.documents[*policyName] = *policyData
+
+	return &policy, nil
+}
+
+func (f *fakeIAMManager) CreateRole(roleName, assumeRolePolicyDocument, _ *string, tags []iamType.Tag) (*iamType.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.roles[*roleName]; ok {
+		return nil, nil
+	}
+
+	role := iamType.Role{
+		Arn:                      aws.String(fmt.Sprintf("arn:aws:iam::%s:role%s%s", testAccountID, f.pathPrefix, *roleName)),
+		AssumeRolePolicyDocument: assumeRolePolicyDocument,
+		Path:                     aws.String(f.pathPrefix),
+		RoleId:                   aws.String("ID" + strings.ToUpper(*roleName)),
+		RoleName:                 roleName,
+		Tags:                     tags,
+	}
+	f.roles[*roleName] = role
+
+	return &role, nil
+}
+
+func (f *fakeIAMManager) DeletePolicy(policyName *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.policies, *policyName)
+	delete(f.documents, *policyName)
+
+	return nil
+}
+
+func (f *fakeIAMManager) DeleteRole(roleName *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.roles, *roleName)
+	delete(f.attached, *roleName)
+
+	return nil
+}
+
+func (f *fakeIAMManager) DetachRolePolicy(policyName, roleName *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.attached[*roleName], *policyName)
+
+	return nil
+}
+
+func (f *fakeIAMManager) DiffRoleByPolicyDocument(rolePolicyDocumentA, rolePolicyDocumentB *string) (bool, error) {
+	return aws.ToString(rolePolicyDocumentA) != aws.ToString(rolePolicyDocumentB), nil
+}
+
+func (f *fakeIAMManager) GetIAMClientMetadata() *aws_sdk.IAMClientMetadata {
+	return &aws_sdk.IAMClientMetadata{AccountID: testAccountID, PathPrefix: f.pathPrefix, Region: testRegion}
+}
+
+func (f *fakeIAMManager) GetPolicyByName(policyName *string) (*iamType.Policy, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policy, ok := f.policies[*policyName]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &policy, true, nil
+}
+
+func (f *fakeIAMManager) GetPolicyDocument(policy *iamType.Policy) (*string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	document := f.documents[*policy.PolicyName]
+
+	return &document, nil
+}
+
+func (f *fakeIAMManager) GetRoleByName(roleName *string) (*iamType.Role, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role, ok := f.roles[*roleName]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &role, true, nil
+}
+
+func (f *fakeIAMManager) ListAttachedRolePolicies(roleName *string) ([]iamType.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["ListAttachedRolePolicies"]++
+
+	var policies []iamType.Policy
+	for policyName := range f.attached[*roleName] {
+		if policy, ok := f.policies[policyName]; ok {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, nil
+}
+
+func (f *fakeIAMManager) ListEntitiesForPolicy(policy *iamType.Policy) ([]iamType.PolicyRole, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["ListEntitiesForPolicy"]++
+
+	var entities []iamType.PolicyRole
+	for roleName, policies := range f.attached {
+		if _, ok := policies[*policy.PolicyName]; ok {
+			entities = append(entities, iamType.PolicyRole{RoleName: aws.String(roleName)})
+		}
+	}
+
+	return entities, nil
+}
+
+func (f *fakeIAMManager) ListPoliciesByTags(tags []iamType.Tag) ([]iamType.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var policies []iamType.Policy
+	for _, policy := range f.policies {
+		if hasTags(policy.Tags, tags) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, nil
+}
+
+func (f *fakeIAMManager) ListRolesByTags(tags []iamType.Tag) ([]iamType.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var roles []iamType.Role
+	for _, role := range f.roles {
+		if hasTags(role.Tags, tags) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+func (f *fakeIAMManager) TagPolicy(policyName *string, tags []iamType.Tag) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policy := f.policies[*policyName]
+	policy.Tags = mergeTags(policy.Tags, tags)
+	f.policies[*policyName] = policy
+
+	return nil
+}
+
+func (f *fakeIAMManager) TagRole(roleName *string, tags []iamType.Tag) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role := f.roles[*roleName]
+	role.Tags = mergeTags(role.Tags, tags)
+	f.roles[*roleName] = role
+
+	return nil
+}
+
+func (f *fakeIAMManager) UpdateRole(roleName, assumeRolePolicyDocument *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role := f.roles[*roleName]
+	role.AssumeRolePolicyDocument = assumeRolePolicyDocument
+	f.roles[*roleName] = role
+
+	return nil
+}
+
+func oidcProviderARN(clusterName string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:oidc-provider/oidc.eks.%s.amazonaws.com/id/%s",
+		testAccountID, testRegion, strings.ToUpper(clusterName))
+}
+
+// newTestTrust returns the trust of the role assumed by the ServiceAccount.
+func newTestTrust(namespace, name string) *iamv1alpha1.RoleTrust {
+	return &iamv1alpha1.RoleTrust{ServiceAccounts: []iamv1alpha1.ServiceAccountReference{{Namespace: namespace, Name: name}}}
+}
+
+// newTestTemplateSpec returns the spec of the AWSIAMProvision CRs generated for the clusters, the names are rendered
+// by the default naming scheme of the generated CRs and the policy refers to the cluster by the template data.
+func newTestTemplateSpec() iamv1alpha1.AWSIAMProvisionSpec {
+	return iamv1alpha1.AWSIAMProvisionSpec{
+		Region: testRegion,
+		Policies: map[string]iamv1alpha1.AWSIAMProvisionPolicy{
+			"reader": {Spec: iamv1alpha1.PolicySpec{
+				Name: aws.String("reader"),
+				Statements: []iamv1alpha1.PolicyStatement{{
+					Action:   []string{"s3:GetObject"},
+					Resource: []string{"arn:{{ .Partition }}:s3:::{{ .ClusterName }}/*"},
+				}},
+			}},
+		},
+		Roles: map[string]iamv1alpha1.AWSIAMProvisionRole{
+			"reader": {Spec: iamv1alpha1.RoleSpec{
+				Name:     aws.String("reader"),
+				Policies: []*string{aws.String("reader")},
+				Trust:    newTestTrust("default", "reader"),
+			}},
+		},
+	}
+}
+
+// newTestObjects returns the ready AWSManagedControlPlane of the cluster and the AWSIAMProvision of the cluster
+// with two roles sharing a policy.
+func newTestObjects(clusterName string) []client.Object {
+	eksCP := &ekscontrolplanev1.AWSManagedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: testNamespace},
+	}
+	eksCP.Status.Ready = true
+	eksCP.Status.OIDCProvider.ARN = oidcProviderARN(clusterName)
+
+	air := &iamv1alpha1.AWSIAMProvision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       clusterName,
+			Namespace:  testNamespace,
+			Generation: 1,
+			UID:        types.UID(clusterName + "-uid"),
+		},
+		Spec: iamv1alpha1.AWSIAMProvisionSpec{
+			EKSClusterName: clusterName,
+			Region:         testRegion,
+			Policies: map[string]iamv1alpha1.AWSIAMProvisionPolicy{
+				"policy": {Spec: iamv1alpha1.PolicySpec{
+					Name:           aws.String(clusterName + "-policy"),
+					PolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:ListBucket","Resource":"*"}]}`),
+				}},
+			},
+			Roles: map[string]iamv1alpha1.AWSIAMProvisionRole{
+				"role": {Spec: iamv1alpha1.RoleSpec{
+					AssumeRolePolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow",` +
+						`"Principal":{"Federated":"{{ .OIDCProviderARN }}"},"Action":"sts:AssumeRoleWithWebIdentity"}]}`),
+					Name:     aws.String(clusterName + "-role"),
+					Policies: []*string{aws.String(clusterName + "-policy")},
+				}},
+				"reader": {Spec: iamv1alpha1.RoleSpec{
+					Trust:    newTestTrust("default", "reader"),
+					Name:     aws.String(clusterName + "-reader"),
+					Policies: []*string{aws.String(clusterName + "-policy")},
+				}},
+			},
+		},
+	}
+
+	return []client.Object{eksCP, air}
+}
+
+func newTestReconciler(t *testing.T, iamManager aws_sdk.IAMManager, objects ...client.Object) *AWSIAMProvisionReconciler {
+	scheme := runtime.NewScheme()
+	if err := iamv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ekscontrolplanev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ackiamv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	return &AWSIAMProvisionReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&iamv1alpha1.AWSIAMProvision{}, &iamv1alpha1.AWSIAMProvisionFleet{},
+				&iamv1alpha1.AWSIAMProvisionTemplate{}).
+			Build(),
+		IAMWorkers: 4,
+		NewIAMClient: func(_ context.Context, _, _ string, _ logr.Logger) (aws_sdk.IAMManager, error) {
+			return iamManager, nil
+		},
+		Scheme: scheme,
+	}
+}
+
+// testKey returns the key of the object in the test namespace.
+func testKey(name string) types.NamespacedName {
+	return types.NamespacedName{Name: name, Namespace: testNamespace}
+}
+
+// mustReconcile reconciles the object and fails the test on error.
+func mustReconcile(t *testing.T, r reconcile.Reconciler, key types.NamespacedName) {
+	t.Helper()
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustGet reads the object and fails the test on error.
+func mustGet(t *testing.T, c client.Reader, key types.NamespacedName, obj client.Object) {
+	t.Helper()
+
+	if err := c.Get(context.Background(), key, obj); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reconcileGenerated reconciles the owner of the generated AWSIAMProvision CRs, then all the AWSIAMProvision CRs,
+// then the owner again, so the status of the owner reflects the state of the synced CRs.
+func reconcileGenerated(t *testing.T, r *AWSIAMProvisionReconciler, owner reconcile.Reconciler, ownerKey types.NamespacedName) {
+	t.Helper()
+
+	mustReconcile(t, owner, ownerKey)
+
+	generated := &iamv1alpha1.AWSIAMProvisionList{}
+	if err := r.List(context.Background(), generated); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, air := range generated.Items {
+		mustReconcile(t, r, client.ObjectKeyFromObject(&air))
+	}
+
+	mustReconcile(t, owner, ownerKey)
+}
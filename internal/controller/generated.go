@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// generatedNameTemplate - the naming scheme of the IAM resources of the AWSIAMProvision CRs generated for
+// the clusters unless the template or the operator sets one, the names of the same role of different clusters
+// must not collide.
+const generatedNameTemplate = "{{ .ClusterName }}-{{ .Name }}"
+
+// generatedNameHashLength - the length of the hash suffix of the truncated names of the generated AWSIAMProvision CRs.
+const generatedNameHashLength = 10
+
+// generatedName returns the name of the AWSIAMProvision generated by the owner for the cluster. The name which exceeds
+// the limit of the object names is truncated and suffixed by the hash of the full name, so it stays unique.
+func generatedName(ownerName, clusterName string) string {
+	name := ownerName + "-" + clusterName
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+
+	hash := sha256.Sum256([]byte(name))
+	prefix := strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-generatedNameHashLength-1], "-.")
+
+	return prefix + "-" + hex.EncodeToString(hash[:])[:generatedNameHashLength]
+}
+
+// generatedSpec returns the spec of the AWSIAMProvision of the cluster instantiated from the template.
+func generatedSpec(template *iamv1alpha1.AWSIAMProvisionSpec, eksClusterName,
+	iamNameTemplate string) iamv1alpha1.AWSIAMProvisionSpec {
+	spec := *template.DeepCopy()
+	spec.EKSClusterName = eksClusterName
+	if spec.NameTemplate == nil && len(iamNameTemplate) == 0 {
+		nameTemplate := generatedNameTemplate
+		spec.NameTemplate = &nameTemplate
+	}
+
+	return spec
+}
+
+// syncGeneratedAWSIAMProvision creates or patches the AWSIAMProvision controlled by the owner,
+// the existing AWSIAMProvision controlled by another object is never adopted.
+func syncGeneratedAWSIAMProvision(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object,
+	awsIAMProvision *iamv1alpha1.AWSIAMProvision, labels map[string]string, spec iamv1alpha1.AWSIAMProvisionSpec) error {
+	result, err := controllerutil.CreateOrPatch(ctx, c, awsIAMProvision, func() error {
+		if !awsIAMProvision.CreationTimestamp.IsZero() && !metav1.IsControlledBy(awsIAMProvision, owner) {
+			return fmt.Errorf("AWSIAMProvision %s/%s already exists and is not controlled by %s",
+				awsIAMProvision.Namespace, awsIAMProvision.Name, owner.GetName())
+		}
+
+		if awsIAMProvision.Labels == nil {
+			awsIAMProvision.Labels = make(map[string]string, len(labels))
+		}
+
+		for key, value := range labels {
+			awsIAMProvision.Labels[key] = value
+		}
+
+		awsIAMProvision.Spec = spec
+
+		return controllerutil.SetControllerReference(owner, awsIAMProvision, scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	if result != controllerutil.OperationResultNone {
+		log.FromContext(ctx).Info("generated AWSIAMProvision synced",
+			"awsIAMProvision", awsIAMProvision.Name, "operation", result)
+	}
+
+	return nil
+}
+
+// generatedClusterStatus summarizes the state of the AWSIAMProvision of the cluster.
+func generatedClusterStatus(clusterName string,
+	awsIAMProvision *iamv1alpha1.AWSIAMProvision) iamv1alpha1.AWSIAMProvisionFleetStatusCluster {
+	status := iamv1alpha1.AWSIAMProvisionFleetStatusCluster{
+		AWSIAMProvision: awsIAMProvision.Name,
+		Message:         awsIAMProvision.Status.Message,
+		Name:            clusterName,
+		Phase:           awsIAMProvision.Status.Phase,
+		Ready:           metav1.ConditionUnknown,
+	}
+
+	if condition := meta.FindStatusCondition(awsIAMProvision.Status.Conditions, iamv1alpha1.ConditionTypeReady); condition != nil &&
+		condition.ObservedGeneration == awsIAMProvision.Generation {
+		status.Ready = condition.Status
+	}
+
+	return status
+}
+
+// sortGeneratedClusters sorts the clusters by name and returns the number of the ready ones.
+func sortGeneratedClusters(clusters []iamv1alpha1.AWSIAMProvisionFleetStatusCluster) int32 {
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+
+	var ready int32
+	for _, cluster := range clusters {
+		if cluster.Ready == metav1.ConditionTrue {
+			ready++
+		}
+	}
+
+	return ready
+}
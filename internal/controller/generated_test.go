@@ -0,0 +1,48 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// TestGeneratedName checks the names of the generated AWSIAMProvision CRs are kept as is while they fit the limit
+// of the object names, the longer ones are truncated and stay unique and valid.
+func TestGeneratedName(t *testing.T) {
+	if got := generatedName("platform", "cluster-a"); got != "platform-cluster-a" {
+		t.Errorf("expected the short name kept, got %s", got)
+	}
+
+	ownerName := strings.Repeat("platform.", 30) + "fleet"
+	nameA, nameB := generatedName(ownerName, "cluster-a"), generatedName(ownerName, "cluster-b")
+	if nameA == nameB {
+		t.Errorf("truncated names of different clusters collide: %s", nameA)
+	}
+
+	for _, name := range []string{nameA, nameB} {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			t.Errorf("truncated name %s is not valid: %v", name, errs)
+		}
+	}
+
+	if generatedName(ownerName, "cluster-a") != nameA {
+		t.Error("truncated name is not stable")
+	}
+}
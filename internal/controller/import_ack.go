@@ -0,0 +1,333 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// ackTagPrefix - the prefix of the tags which ACK adds to the AWS resources, they are not imported.
+const ackTagPrefix = "services.k8s.aws/"
+
+var (
+	// oidcProviderARNPattern matches the ARNs of the IAM OIDC providers in the trust relationship policy documents.
+	oidcProviderARNPattern = regexp.MustCompile(`arn:aws[a-z-]*:iam::[0-9]{12}:oidc-provider/[^"\s]+`)
+	// policyARNPattern matches the ARNs of the customer managed policies, the path and the name are captured.
+	policyARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:policy(/(?:.*/)?)([^/]+)$`)
+)
+
+// ACKImport defines the AWSIAMProvision which the ACK Role and Policy CRs are imported into.
+type ACKImport struct {
+	// ClusterSource - the type of the cluster source of the AWSIAMProvision, CAPA by default.
+	// The OIDC type is set up with the OIDC provider of the trust relationship policy documents.
+	ClusterSource  string
+	EKSClusterName string
+	Name           string
+	Namespace      string
+	// OIDCProviderARN - the ARN which is replaced with the `{{ .OIDCProviderARN }}` placeholder,
+	// it is detected in the trust relationship policy documents if empty.
+	OIDCProviderARN string
+	// Region - if empty, it is taken from the status or the region annotation of the ACK CRs.
+	Region string
+}
+
+// ACKImportResult - the AWSIAMProvision equivalent to the ACK CRs and the warnings about the settings
+// of the ACK CRs which can not be expressed in the AWSIAMProvision.
+type ACKImportResult struct {
+	AWSIAMProvision *iamv1alpha1.AWSIAMProvision
+	Warnings        []string
+}
+
+// escapeDocumentTemplate escapes the template actions of the document to be rendered as is,
+// the closing delimiters are literal outside the actions.
+func escapeDocumentTemplate(document string) string {
+	return strings.ReplaceAll(document, "{{", `{{ "{{" }}`)
+}
+
+// templateOIDCProvider replaces the ARN and the name of the OIDC provider in the trust relationship
+// policy document with the placeholders of the document template.
+func templateOIDCProvider(document, oidcProviderARN string) string {
+	document = escapeDocumentTemplate(document)
+	if len(oidcProviderARN) == 0 {
+		return document
+	}
+
+	_, oidcProviderName, _ := strings.Cut(oidcProviderARN, "/")
+
+	return strings.NewReplacer(
+		oidcProviderARN, "{{ .OIDCProviderARN }}",
+		oidcProviderName, "{{ .OIDCProviderName }}",
+	).Replace(document)
+}
+
+// detectOIDCProviderARN returns the only OIDC provider found in the trust relationship policy documents of the roles.
+func detectOIDCProviderARN(roles []ackiamv1alpha1.Role) (string, error) {
+	found := make(map[string]struct{})
+	for _, role := range roles {
+		for _, arn := range oidcProviderARNPattern.FindAllString(aws.ToString(role.Spec.AssumeRolePolicyDocument), -1) {
+			found[arn] = struct{}{}
+		}
+	}
+
+	arns := sortedMapKeys(found)
+	if len(arns) > 1 {
+		return "", fmt.Errorf("several OIDC providers found in the trust relationship policy documents, "+
+			"the one of the cluster must be set explicitly: %s", strings.Join(arns, ", "))
+	}
+
+	if len(arns) == 0 {
+		return "", nil
+	}
+
+	return arns[0], nil
+}
+
+func importACKTags(tags []*ackiamv1alpha1.Tag) []*iamv1alpha1.Tag {
+	var imported []*iamv1alpha1.Tag
+	for _, tag := range tags {
+		key := aws.ToString(tag.Key)
+		if strings.HasPrefix(key, ackTagPrefix) || strings.HasPrefix(key, "aws.edenlab.io/aws-iam-provisioner/") {
+			continue
+		}
+
+		imported = append(imported, &iamv1alpha1.Tag{Key: tag.Key, Value: tag.Value})
+	}
+
+	return imported
+}
+
+// ImportACK converts the ACK Role and Policy CRs into the equivalent AWSIAMProvision. The roles and policies
+// are keyed by the names of their ACK CRs, the names of the IAM resources are kept by the `{{ .Name }}` name
+// template and the OIDC provider of the trust relationship policy documents is replaced with the placeholders.
+func ImportACK(opts ACKImport, policies []ackiamv1alpha1.Policy, roles []ackiamv1alpha1.Role) (*ACKImportResult, error) {
+	if len(policies) == 0 && len(roles) == 0 {
+		return nil, fmt.Errorf("no ACK Role or Policy found")
+	}
+
+	oidcProviderARN := opts.OIDCProviderARN
+	if len(oidcProviderARN) == 0 {
+		var err error
+		if oidcProviderARN, err = detectOIDCProviderARN(roles); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ACKImportResult{}
+	warn := func(format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	// The operator manages all the roles and policies of the CR in one IAM path.
+	paths := make(map[string]struct{})
+	region := opts.Region
+	setCommon := func(object metav1.Object, path *string, metadata *ackv1alpha1.ResourceMetadata) {
+		if path == nil {
+			path = aws.String(aws_sdk.DefaultPathPrefix)
+		}
+
+		paths[*path] = struct{}{}
+		if len(region) == 0 && metadata != nil && metadata.Region != nil {
+			region = string(*metadata.Region)
+		}
+
+		if len(region) == 0 {
+			region = object.GetAnnotations()[ackv1alpha1.AnnotationRegion]
+		}
+	}
+
+	spec := iamv1alpha1.AWSIAMProvisionSpec{
+		EKSClusterName: opts.EKSClusterName,
+		NameTemplate:   aws.String("{{ .Name }}"),
+		Policies:       make(map[string]iamv1alpha1.AWSIAMProvisionPolicy, len(policies)),
+		Roles:          make(map[string]iamv1alpha1.AWSIAMProvisionRole, len(roles)),
+	}
+
+	policyNames := make(map[string]string, len(policies))
+	for _, policy := range policies {
+		setCommon(&policy, policy.Spec.Path, policy.Status.ACKResourceMetadata)
+		policyNames[policy.Name] = aws.ToString(policy.Spec.Name)
+		spec.Policies[policy.Name] = iamv1alpha1.AWSIAMProvisionPolicy{
+			Spec: iamv1alpha1.PolicySpec{
+				Name:           policy.Spec.Name,
+				PolicyDocument: aws.String(escapeDocumentTemplate(aws.ToString(policy.Spec.PolicyDocument))),
+				Tags:           importACKTags(policy.Spec.Tags),
+			},
+		}
+	}
+
+	importedPolicies := make(map[string]struct{}, len(policyNames))
+	for _, name := range policyNames {
+		importedPolicies[name] = struct{}{}
+	}
+
+	for _, role := range roles {
+		setCommon(&role, role.Spec.Path, role.Status.ACKResourceMetadata)
+
+		if len(role.Spec.InlinePolicies) > 0 {
+			warn("role %s: inline policies are not supported, they are left in AWS IAM as is", role.Name)
+		}
+
+		if role.Spec.PermissionsBoundary != nil || role.Spec.PermissionsBoundaryRef != nil {
+			warn("role %s: the permissions boundary is not supported, it is left in AWS IAM as is", role.Name)
+		}
+
+		var rolePolicies []*string
+		for _, ref := range role.Spec.PolicyRefs {
+			if ref == nil || ref.From == nil {
+				continue
+			}
+
+			refNamespace := aws.ToString(ref.From.Namespace)
+			name, ok := policyNames[aws.ToString(ref.From.Name)]
+			if !ok || (len(refNamespace) > 0 && refNamespace != opts.Namespace) {
+				warn("role %s: the referenced Policy %s is not imported, it stays attached but is not managed",
+					role.Name, aws.ToString(ref.From.Name))
+				continue
+			}
+
+			rolePolicies = append(rolePolicies, aws.String(name))
+		}
+
+		for _, policyARN := range role.Spec.Policies {
+			matches := policyARNPattern.FindStringSubmatch(aws.ToString(policyARN))
+			if matches == nil {
+				warn("role %s: policy %s is not a customer managed policy, it stays attached but is not managed",
+					role.Name, aws.ToString(policyARN))
+				continue
+			}
+
+			if _, ok := importedPolicies[matches[2]]; !ok {
+				warn("role %s: policy %s is not imported, it stays attached but is not managed",
+					role.Name, aws.ToString(policyARN))
+				continue
+			}
+
+			rolePolicies = append(rolePolicies, aws.String(matches[2]))
+		}
+
+		sort.Slice(rolePolicies, func(i, j int) bool { return *rolePolicies[i] < *rolePolicies[j] })
+		spec.Roles[role.Name] = iamv1alpha1.AWSIAMProvisionRole{
+			Spec: iamv1alpha1.RoleSpec{
+				AssumeRolePolicyDocument: aws.String(templateOIDCProvider(
+					aws.ToString(role.Spec.AssumeRolePolicyDocument), oidcProviderARN)),
+				Name:     role.Spec.Name,
+				Policies: rolePolicies,
+				Tags:     importACKTags(role.Spec.Tags),
+			},
+		}
+	}
+
+	if len(paths) > 1 {
+		return nil, fmt.Errorf("the roles and policies of one AWSIAMProvision must share the IAM path, found: %s",
+			strings.Join(sortedMapKeys(paths), ", "))
+	}
+
+	if len(region) == 0 {
+		return nil, fmt.Errorf("the region is not found in the ACK CRs and must be set explicitly")
+	}
+
+	spec.Region = region
+	for path := range paths {
+		spec.Path = aws.String(path)
+	}
+
+	switch opts.ClusterSource {
+	case "", iamv1alpha1.ClusterSourceCAPA:
+	case iamv1alpha1.ClusterSourceOIDC:
+		if len(oidcProviderARN) == 0 {
+			return nil, fmt.Errorf("the OIDC provider is not found in the trust relationship policy documents " +
+				"and must be set explicitly for the OIDC cluster source")
+		}
+
+		spec.ClusterSource = &iamv1alpha1.ClusterSource{
+			Type: iamv1alpha1.ClusterSourceOIDC,
+			OIDC: &iamv1alpha1.OIDCProviderSource{ProviderARN: oidcProviderARN},
+		}
+	default:
+		spec.ClusterSource = &iamv1alpha1.ClusterSource{Type: opts.ClusterSource}
+	}
+
+	result.AWSIAMProvision = &iamv1alpha1.AWSIAMProvision{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: iamv1alpha1.GroupVersion.String(),
+			Kind:       "AWSIAMProvision",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+		},
+		Spec: spec,
+	}
+
+	return result, nil
+}
+
+// HandOverACKResources hands the AWS IAM resources of the imported ACK CRs over to the operator without recreating
+// them: the ACK CRs are deleted with the retain deletion policy, and the roles and policies are tagged by the cluster
+// and namespace of the AWSIAMProvision, so the operator adopts them on the first reconciliation.
+// The AWSIAMProvision is created last, after the IAM resources are released by ACK.
+func HandOverACKResources(ctx context.Context, k8sClient client.Client, iamClient aws_sdk.IAMManager,
+	result *ACKImportResult, policies []ackiamv1alpha1.Policy, roles []ackiamv1alpha1.Role) error {
+	var objects []client.Object
+	for i := range policies {
+		objects = append(objects, &policies[i])
+	}
+
+	for i := range roles {
+		objects = append(objects, &roles[i])
+	}
+
+	for _, object := range objects {
+		patch := client.MergeFrom(object.DeepCopyObject().(client.Object))
+		annotations := object.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+
+		annotations[ackv1alpha1.AnnotationDeletionPolicy] = string(ackv1alpha1.DeletionPolicyRetain)
+		object.SetAnnotations(annotations)
+		if err := k8sClient.Patch(ctx, object, patch); err != nil {
+			return fmt.Errorf("unable to set the retain deletion policy of %s: %w", client.ObjectKeyFromObject(object), err)
+		}
+
+		if err := k8sClient.Delete(ctx, object); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete %s: %w", client.ObjectKeyFromObject(object), err)
+		}
+	}
+
+	awsIAMProvision := result.AWSIAMProvision
+	owner := &aws_sdk.ResourceOwner{
+		ClusterName: awsIAMProvision.Spec.EKSClusterName,
+		Namespace:   awsIAMProvision.Namespace,
+	}
+
+	for _, key := range sortedMapKeys(awsIAMProvision.Spec.Policies) {
+		if err := iamClient.TagPolicy(awsIAMProvision.Spec.Policies[key].Spec.Name, owner.LegacyTags()); err != nil {
+			return fmt.Errorf("unable to tag policy %s: %w", *awsIAMProvision.Spec.Policies[key].Spec.Name, err)
+		}
+	}
+
+	for _, key := range sortedMapKeys(awsIAMProvision.Spec.Roles) {
+		if err := iamClient.TagRole(awsIAMProvision.Spec.Roles[key].Spec.Name, owner.LegacyTags()); err != nil {
+			return fmt.Errorf("unable to tag role %s: %w", *awsIAMProvision.Spec.Roles[key].Spec.Name, err)
+		}
+	}
+
+	if err := k8sClient.Create(ctx, awsIAMProvision.DeepCopy()); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create AWSIAMProvision %s: %w", client.ObjectKeyFromObject(awsIAMProvision), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	ackiamv1alpha1 "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestImportACK converts the ACK CRs into the AWSIAMProvision and hands their IAM resources over without recreating them.
+func TestImportACK(t *testing.T) {
+	const clusterName = "imported"
+
+	ctx := context.Background()
+	trustDocument := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow",`+
+		`"Principal":{"Federated":"%s"},"Action":"sts:AssumeRoleWithWebIdentity",`+
+		`"Condition":{"StringEquals":{"oidc.eks.%s.amazonaws.com/id/IMPORTED:sub":"system:serviceaccount:default:app"}}}]}`,
+		oidcProviderARN(clusterName), testRegion)
+	policyDocument := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:ListBucket","Resource":"*"}]}`
+
+	ackPolicy := &ackiamv1alpha1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: testNamespace},
+		Spec: ackiamv1alpha1.PolicySpec{
+			Name:           aws.String(clusterName + "-policy"),
+			PolicyDocument: aws.String(policyDocument),
+			Tags: []*ackiamv1alpha1.Tag{
+				{Key: aws.String("services.k8s.aws/namespace"), Value: aws.String(testNamespace)},
+				{Key: aws.String("team"), Value: aws.String("storage")},
+			},
+		},
+	}
+	ackRole := &ackiamv1alpha1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: testNamespace},
+		Spec: ackiamv1alpha1.RoleSpec{
+			AssumeRolePolicyDocument: aws.String(trustDocument),
+			Name:                     aws.String(clusterName + "-role"),
+			Policies:                 []*string{aws.String("arn:aws:iam::aws:policy/ReadOnlyAccess")},
+			PolicyRefs: []*ackv1alpha1.AWSResourceReferenceWrapper{
+				{From: &ackv1alpha1.AWSResourceReference{Name: aws.String("policy")}},
+			},
+		},
+	}
+
+	iamManager := newFakeIAMManager()
+	if _, err := iamManager.CreatePolicy(ackPolicy.Spec.Name, ackPolicy.Spec.PolicyDocument, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := iamManager.CreateRole(ackRole.Spec.Name, ackRole.Spec.AssumeRolePolicyDocument, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := iamManager.AttachRolePolicy(ackPolicy.Spec.Name, ackRole.Spec.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestReconciler(t, iamManager, ackPolicy, ackRole)
+	policies, roles := []ackiamv1alpha1.Policy{*ackPolicy}, []ackiamv1alpha1.Role{*ackRole}
+	result, err := ImportACK(ACKImport{
+		ClusterSource:  iamv1alpha1.ClusterSourceOIDC,
+		EKSClusterName: clusterName,
+		Name:           clusterName,
+		Namespace:      testNamespace,
+		Region:         testRegion,
+	}, policies, roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := result.AWSIAMProvision.Spec
+	role := spec.Roles["role"].Spec
+	if !strings.Contains(*role.AssumeRolePolicyDocument, `"Federated":"{{ .OIDCProviderARN }}"`) ||
+		!strings.Contains(*role.AssumeRolePolicyDocument, `"{{ .OIDCProviderName }}:sub"`) {
+		t.Errorf("OIDC provider is not templated: %s", *role.AssumeRolePolicyDocument)
+	}
+
+	if len(role.Policies) != 1 || *role.Policies[0] != clusterName+"-policy" || len(result.Warnings) != 1 {
+		t.Errorf("unexpected role policies %v, warnings %v", role.Policies, result.Warnings)
+	}
+
+	if tags := spec.Policies["policy"].Spec.Tags; len(tags) != 1 || *tags[0].Key != "team" {
+		t.Errorf("unexpected policy tags %v", tags)
+	}
+
+	if spec.ClusterSource.OIDC.ProviderARN != oidcProviderARN(clusterName) {
+		t.Errorf("unexpected cluster source %+v", spec.ClusterSource.OIDC)
+	}
+
+	if err := HandOverACKResources(ctx, r.Client, iamManager, result, policies, roles); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(ackRole), &ackiamv1alpha1.Role{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("ACK Role is not deleted: %v", err)
+	}
+
+	key := testKey(clusterName)
+	air := &iamv1alpha1.AWSIAMProvision{}
+	mustGet(t, r, key, air)
+
+	// The fake client does not set the UID of the created objects.
+	air.UID = types.UID(clusterName + "-uid")
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	owner := &aws_sdk.ResourceOwner{ClusterName: clusterName, Name: clusterName, Namespace: testNamespace, UID: string(air.UID)}
+	iamRole := iamManager.roles[clusterName+"-role"]
+	if !hasTags(iamRole.Tags, owner.IdentityTags()) || !hasTags(iamManager.policies[clusterName+"-policy"].Tags, owner.IdentityTags()) {
+		t.Errorf("IAM resources are not adopted: %v", iamRole.Tags)
+	}
+
+	if *iamRole.AssumeRolePolicyDocument != trustDocument {
+		t.Errorf("trust relationship policy document changed: %s", *iamRole.AssumeRolePolicyDocument)
+	}
+
+	if _, ok := iamManager.attached[clusterName+"-role"][clusterName+"-policy"]; !ok {
+		t.Error("policy is detached from the role")
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+)
+
+// runParallel runs the tasks by at most the number of workers goroutines,
+// the errors of all the tasks are returned sorted by message to keep the status of the CR stable.
+func runParallel(workers int, tasks []func() error) []error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		errs []error
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+
+	semaphore := make(chan struct{}, workers)
+	for _, task := range tasks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(task func() error) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			if err := task(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	return errs
+}
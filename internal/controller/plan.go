@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// Plan - the actions which the reconciliation of an AWSIAMProvision would apply to AWS IAM.
+type Plan struct {
+	Actions []aws_sdk.Action
+	// ConflictPolicies, ConflictRoles - names of the IAM resources owned by another CR, they would be skipped.
+	ConflictPolicies []string
+	ConflictRoles    []string
+	PathPrefix       string
+}
+
+// Plan computes the actions which the reconciliation of the CR would apply to AWS IAM, AWS IAM is only read.
+// The CR and its AWSManagedControlPlane are passed as is, so they are not required to exist in the cluster.
+// If the AWSManagedControlPlane is nil, the cluster is resolved by the cluster source of the CR.
+func (r *AWSIAMProvisionReconciler) Plan(ctx context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision,
+	eksCP *ekscontrolplanev1.AWSManagedControlPlane) (*Plan, error) {
+	rm, air := r.newOfflineReconciliationManager(ctx, awsIAMProvision)
+
+	var err error
+	if eksCP != nil {
+		air.cluster, err = capaCluster(air.awsIAMProvision, eksCP)
+	} else {
+		_, clusterSource := rm.getClusterSource(air)
+		air.cluster, err = clusterSource.GetCluster(ctx, air.awsIAMProvision)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rm.resolveIAMResourcesSpec(air); err != nil {
+		return nil, err
+	}
+
+	if err := rm.resolveVariables(air); err != nil {
+		return nil, err
+	}
+
+	if err := rm.resolvePolicyTemplates(air); err != nil {
+		return nil, err
+	}
+
+	newIAMClient := rm.NewIAMClient
+	if newIAMClient == nil {
+		newIAMClient = NewIAMClient
+	}
+
+	iamClient, err := newIAMClient(ctx, air.awsIAMProvision.Spec.Region, rm.getIAMPathPrefix(air), rm.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRunIAMClient := aws_sdk.NewDryRunIAMManager(iamClient)
+	rm.IAMClient = dryRunIAMClient
+
+	if err := rm.syncIAMResources(air); err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		Actions:          dryRunIAMClient.Actions(),
+		ConflictPolicies: sortedKeys(air.conflictPolicies),
+		ConflictRoles:    sortedKeys(air.conflictRoles),
+		PathPrefix:       iamClient.GetIAMClientMetadata().PathPrefix,
+	}, nil
+}
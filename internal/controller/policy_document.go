@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// policyDocumentVersion - the version of the IAM policy language.
+const policyDocumentVersion = "2012-10-17"
+
+// policyDocument - the IAM policy document, it is marshaled with the keys of the maps sorted, so deterministically.
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+// policyStatement - a statement of the IAM policy document. The lists of one element are marshaled as strings
+// the same as IAM returns them.
+type policyStatement struct {
+	Sid       string                            `json:"Sid,omitempty"`
+	Effect    string                            `json:"Effect"`
+	Principal map[string]policyValue            `json:"Principal,omitempty"`
+	Action    policyValue                       `json:"Action,omitempty"`
+	NotAction policyValue                       `json:"NotAction,omitempty"`
+	Resource  policyValue                       `json:"Resource,omitempty"`
+	Condition map[string]map[string]policyValue `json:"Condition,omitempty"`
+}
+
+// policyValue - a string or a list of strings of the IAM policy document.
+type policyValue []string
+
+func (v policyValue) MarshalJSON() ([]byte, error) {
+	if len(v) == 1 {
+		return json.Marshal(v[0])
+	}
+
+	return json.Marshal([]string(v))
+}
+
+// marshalPolicyDocument marshals the document without escaping HTML characters, e.g. `>` of the ARNs.
+func marshalPolicyDocument(document *policyDocument) (string, error) {
+	var data strings.Builder
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(data.String()), nil
+}
+
+// addCondition adds the values of the condition key, the keys defined twice are rejected.
+func addCondition(conditions map[string]map[string]policyValue, test, variable string, values []string) error {
+	if _, ok := conditions[test]; !ok {
+		conditions[test] = make(map[string]policyValue)
+	}
+
+	if _, ok := conditions[test][variable]; ok {
+		return fmt.Errorf("condition %s of %s is defined twice", test, variable)
+	}
+
+	conditions[test][variable] = values
+
+	return nil
+}
+
+// templateActionPattern - the actions of the document template, they are JSON-escaped when the document is marshaled.
+var templateActionPattern = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// compilePolicyStatements serializes the structured statements into the policy document template,
+// the template actions of the values are kept as is to be rendered the same as the ones of the string documents.
+func compilePolicyStatements(statements []iamv1alpha1.PolicyStatement) (string, error) {
+	document := &policyDocument{Version: policyDocumentVersion}
+	for _, statement := range statements {
+		effect := statement.Effect
+		if len(effect) == 0 {
+			effect = "Allow"
+		}
+
+		compiled := policyStatement{
+			Sid:       statement.Sid,
+			Effect:    effect,
+			Action:    statement.Action,
+			NotAction: statement.NotAction,
+			Resource:  statement.Resource,
+		}
+
+		if len(statement.Principal) > 0 {
+			compiled.Principal = make(map[string]policyValue, len(statement.Principal))
+			for _, principal := range statement.Principal {
+				compiled.Principal[principal.Type] = append(compiled.Principal[principal.Type], principal.Identifiers...)
+			}
+		}
+
+		if len(statement.Condition) > 0 {
+			compiled.Condition = make(map[string]map[string]policyValue, len(statement.Condition))
+			for _, condition := range statement.Condition {
+				if err := addCondition(compiled.Condition, condition.Test, condition.Variable, condition.Values); err != nil {
+					return "", err
+				}
+			}
+		}
+
+		document.Statement = append(document.Statement, compiled)
+	}
+
+	data, err := marshalPolicyDocument(document)
+	if err != nil {
+		return "", err
+	}
+
+	var actionErr error
+	data = templateActionPattern.ReplaceAllStringFunc(data, func(action string) string {
+		var unescaped string
+		if err := json.Unmarshal([]byte(`"`+action+`"`), &unescaped); err != nil {
+			actionErr = fmt.Errorf("template action %s malformed: %w", action, err)
+		}
+
+		return unescaped
+	})
+
+	return data, actionErr
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+func TestCompilePolicyStatements(t *testing.T) {
+	templateData := &documentTemplateData{Region: testRegion, Variables: map[string]templateString{"bucket": "backups"}}
+
+	for _, tc := range []struct {
+		statements []iamv1alpha1.PolicyStatement
+		expected   string
+		err        bool
+	}{
+		{
+			[]iamv1alpha1.PolicyStatement{{
+				Sid:      "Backups",
+				Action:   []string{"s3:GetObject", "s3:PutObject"},
+				Resource: []string{"arn:aws:s3:::{{ .Variables.bucket }}/*"},
+				Condition: []iamv1alpha1.PolicyCondition{
+					{Test: "StringEquals", Variable: "aws:RequestedRegion", Values: []string{`{{ default "us-east-1" .Region }}`}},
+					{Test: "Bool", Variable: "aws:SecureTransport", Values: []string{"true"}},
+				},
+			}, {
+				Effect:    "Deny",
+				NotAction: []string{"s3:*"},
+				Resource:  []string{"*"},
+				Principal: []iamv1alpha1.PolicyPrincipal{{Type: "AWS", Identifiers: []string{"*"}}},
+			}},
+			`{"Version":"2012-10-17","Statement":[{"Sid":"Backups","Effect":"Allow",` +
+				`"Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::backups/*",` +
+				`"Condition":{"Bool":{"aws:SecureTransport":"true"},"StringEquals":{"aws:RequestedRegion":"` + testRegion + `"}}},` +
+				`{"Effect":"Deny","Principal":{"AWS":"*"},"NotAction":"s3:*","Resource":"*"}]}`,
+			false,
+		},
+		{
+			[]iamv1alpha1.PolicyStatement{{Action: []string{"s3:*"}, Resource: []string{"*"}, Condition: []iamv1alpha1.PolicyCondition{
+				{Test: "Bool", Variable: "aws:SecureTransport", Values: []string{"true"}},
+				{Test: "Bool", Variable: "aws:SecureTransport", Values: []string{"false"}},
+			}}},
+			``,
+			true,
+		},
+	} {
+		document, err := compilePolicyStatements(tc.statements)
+		if err == nil {
+			document, err = renderDocumentTemplate(document, templateData)
+		}
+
+		if tc.err != (err != nil) {
+			t.Errorf("%+v: unexpected error %v", tc.statements, err)
+			continue
+		}
+
+		if document != tc.expected {
+			t.Errorf("%+v: compiled %s, expected %s", tc.statements, document, tc.expected)
+		}
+	}
+}
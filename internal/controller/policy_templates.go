@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// policyTemplateRefField - the field index of AWSIAMProvision by the names of the referenced IAMPolicyTemplates.
+const policyTemplateRefField = ".spec.templateRef.name"
+
+// policyTemplateRefs returns the references of the IAMPolicyTemplates of the policies and roles of the spec.
+func policyTemplateRefs(spec *iamv1alpha1.AWSIAMProvisionSpec) []*iamv1alpha1.PolicyTemplateReference {
+	var refs []*iamv1alpha1.PolicyTemplateReference
+	for _, key := range sortedMapKeys(spec.Policies) {
+		if ref := spec.Policies[key].Spec.TemplateRef; ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+
+	for _, key := range sortedMapKeys(spec.Roles) {
+		if ref := spec.Roles[key].Spec.TrustTemplateRef; ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// resolvePolicyTemplates reads the IAMPolicyTemplates referenced by the spec,
+// the templates which are already supplied, e.g. by the manifest of Render, are not read.
+func (rm *ReconciliationManager) resolvePolicyTemplates(air *awsIAMResources) error {
+	for _, ref := range policyTemplateRefs(&air.awsIAMProvision.Spec) {
+		if _, ok := air.policyTemplates[ref.Name]; ok {
+			continue
+		}
+
+		if rm.Client == nil {
+			return fmt.Errorf("IAMPolicyTemplate %s of %s AWSIAMProvision can not be read without access to the cluster",
+				ref.Name, rm.request.NamespacedName)
+		}
+
+		policyTemplate := &iamv1alpha1.IAMPolicyTemplate{}
+		if err := rm.Get(rm.ctx, types.NamespacedName{Name: ref.Name}, policyTemplate); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return fmt.Errorf("IAMPolicyTemplate %s of %s AWSIAMProvision not found", ref.Name, rm.request.NamespacedName)
+			}
+
+			return fmt.Errorf("unable to get IAMPolicyTemplate %s of %s AWSIAMProvision: %w",
+				ref.Name, rm.request.NamespacedName, err)
+		}
+
+		air.policyTemplates[ref.Name] = policyTemplate
+	}
+
+	return nil
+}
+
+// renderPolicyTemplate renders the document of the referenced IAMPolicyTemplate with the values of the parameters,
+// the unknown parameters and the required ones without values are errors.
+func renderPolicyTemplate(air *awsIAMResources, ref *iamv1alpha1.PolicyTemplateReference) (string, error) {
+	policyTemplate, ok := air.policyTemplates[ref.Name]
+	if !ok {
+		return "", fmt.Errorf("IAMPolicyTemplate %s is not resolved", ref.Name)
+	}
+
+	parameters := make(map[string]templateString, len(policyTemplate.Spec.Parameters))
+	known := make(map[string]struct{}, len(policyTemplate.Spec.Parameters))
+	for _, parameter := range policyTemplate.Spec.Parameters {
+		known[parameter.Name] = struct{}{}
+		if value, ok := ref.Parameters[parameter.Name]; ok {
+			parameters[parameter.Name] = templateString(value)
+		} else if parameter.Default != nil {
+			parameters[parameter.Name] = templateString(*parameter.Default)
+		} else {
+			return "", fmt.Errorf("parameter %s of IAMPolicyTemplate %s is required", parameter.Name, ref.Name)
+		}
+	}
+
+	for _, name := range sortedMapKeys(ref.Parameters) {
+		if _, ok := known[name]; !ok {
+			return "", fmt.Errorf("parameter %s is not defined by IAMPolicyTemplate %s", name, ref.Name)
+		}
+	}
+
+	var document string
+	if len(policyTemplate.Spec.Statements) > 0 {
+		var err error
+		if document, err = compilePolicyStatements(policyTemplate.Spec.Statements); err != nil {
+			return "", fmt.Errorf("statements of IAMPolicyTemplate %s malformed: %w", ref.Name, err)
+		}
+	} else if policyTemplate.Spec.PolicyDocument != nil {
+		document = *policyTemplate.Spec.PolicyDocument
+	}
+
+	templateData := *air.templateData
+	templateData.Parameters = parameters
+
+	rendered, err := renderDocumentTemplate(document, &templateData)
+	if err != nil {
+		return "", fmt.Errorf("document of IAMPolicyTemplate %s malformed: %w", ref.Name, err)
+	}
+
+	return rendered, nil
+}
+
+// policyTemplatesStatus returns the revisions of the IAMPolicyTemplates referenced by the spec.
+func policyTemplatesStatus(air *awsIAMResources) []iamv1alpha1.AWSIAMProvisionStatusPolicyTemplate {
+	revisions := make(map[string]int64)
+	for _, ref := range policyTemplateRefs(&air.awsIAMProvision.Spec) {
+		if policyTemplate, ok := air.policyTemplates[ref.Name]; ok {
+			revisions[ref.Name] = policyTemplate.Generation
+		}
+	}
+
+	var status []iamv1alpha1.AWSIAMProvisionStatusPolicyTemplate
+	for _, name := range sortedMapKeys(revisions) {
+		status = append(status, iamv1alpha1.AWSIAMProvisionStatusPolicyTemplate{Name: name, Revision: revisions[name]})
+	}
+
+	return status
+}
+
+// policyTemplateRefIndexer indexes AWSIAMProvision by the names of the referenced IAMPolicyTemplates.
+func policyTemplateRefIndexer(obj client.Object) []string {
+	names := make(map[string]struct{})
+	for _, ref := range policyTemplateRefs(&obj.(*iamv1alpha1.AWSIAMProvision).Spec) {
+		names[ref.Name] = struct{}{}
+	}
+
+	return sortedMapKeys(names)
+}
+
+// findAWSIAMProvisionsForPolicyTemplate maps the IAMPolicyTemplate to the AWSIAMProvision CRs of all the namespaces
+// which reference it, so the changes of the template are rolled out to them.
+func (r *AWSIAMProvisionReconciler) findAWSIAMProvisionsForPolicyTemplate(ctx context.Context, obj client.Object) []reconcile.Request {
+	awsIAMProvisions := &iamv1alpha1.AWSIAMProvisionList{}
+	if err := r.List(ctx, awsIAMProvisions, client.MatchingFields{policyTemplateRefField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list AWSIAMProvision of IAMPolicyTemplate", "iamPolicyTemplate", obj.GetName())
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(awsIAMProvisions.Items))
+	for _, awsIAMProvision := range awsIAMProvisions.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&awsIAMProvision)})
+	}
+
+	return requests
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// TestReconcilePolicyTemplates renders the policy document of the IAMPolicyTemplate and rolls out its changes.
+func TestReconcilePolicyTemplates(t *testing.T) {
+	const clusterName = "templates"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	policy := air.Spec.Policies["policy"]
+	policy.Spec.PolicyDocument = nil
+	policy.Spec.TemplateRef = &iamv1alpha1.PolicyTemplateReference{
+		Name: "s3", Parameters: map[string]string{"bucket": "backups"}}
+	air.Spec.Policies["policy"] = policy
+
+	policyTemplate := &iamv1alpha1.IAMPolicyTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3"},
+		Spec: iamv1alpha1.IAMPolicyTemplateSpec{
+			Parameters: []iamv1alpha1.IAMPolicyTemplateParameter{
+				{Name: "bucket"}, {Name: "action", Default: aws.String("s3:GetObject")}},
+			Statements: []iamv1alpha1.PolicyStatement{{
+				Action:   []string{"{{ .Parameters.action }}"},
+				Resource: []string{"arn:{{ .Partition }}:s3:::{{ .Parameters.bucket }}/{{ .ClusterName }}"},
+			}},
+		},
+	}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, append(objects, policyTemplate)...)
+	mustReconcile(t, r, key)
+
+	expected := `"Action":"s3:GetObject","Resource":"arn:aws:s3:::backups/` + clusterName + `"`
+	if document := iamManager.documents[clusterName+"-policy"]; !strings.Contains(document, expected) {
+		t.Errorf("template is not rendered: %s", document)
+	}
+
+	mustGet(t, r, client.ObjectKeyFromObject(policyTemplate), policyTemplate)
+
+	policyTemplate.Spec.Parameters[1].Default = aws.String("s3:*")
+	policyTemplate.Generation++
+	if err := r.Update(ctx, policyTemplate); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if document := iamManager.documents[clusterName+"-policy"]; !strings.Contains(document, `"Action":"s3:*"`) {
+		t.Errorf("template change is not rolled out: %s", document)
+	}
+
+	mustGet(t, r, key, air)
+
+	expectedStatus := []iamv1alpha1.AWSIAMProvisionStatusPolicyTemplate{{Name: "s3", Revision: policyTemplate.Generation}}
+	if !reflect.DeepEqual(air.Status.PolicyTemplates, expectedStatus) {
+		t.Errorf("unexpected policy templates status %+v", air.Status.PolicyTemplates)
+	}
+
+	policy.Spec.TemplateRef.Parameters = map[string]string{"unknown": "value"}
+	air.Spec.Policies["policy"] = policy
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Error("expected the error of the unknown and missing parameters")
+	}
+}
@@ -3,123 +3,432 @@ package controller
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"strings"
+	"sort"
+	"sync"
 	"text/template"
 	"time"
 
 	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
 	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
 )
 
 const awsIAMProvisionFinalizerName = "awsiamprovision.iam.aws.edenlab.io/finalizer"
 
 type awsIAMResources struct {
+	// addons - the versions of the add-on bundles expanded into the resolved spec.
+	addons          []iamv1alpha1.AWSIAMProvisionStatusAddon
 	awsIAMProvision *iamv1alpha1.AWSIAMProvision
-	eksCP           *ekscontrolplanev1.AWSManagedControlPlane
-	eksCPNamespace  types.NamespacedName
+	// conflictPolicies, conflictRoles - names of the IAM resources owned by another CR, they are skipped.
+	conflictPolicies map[string]struct{}
+	conflictRoles    map[string]struct{}
+	// mu - guards the status and the conflicts of the CR, the roles and policies are synced in parallel.
+	mu sync.Mutex
+	// dryRun - the CR is reconciled in the Observe mode, the mutations of AWS IAM resources are only recorded.
+	dryRun bool
+	// documentsHash - the hash of the rendered documents of the roles and policies, it is collected by syncIAMResources.
+	documentsHash string
+	// drifted - the remote state diverged from the already synced documents during the current reconciliation.
+	drifted bool
+	// cluster - the EKS cluster resolved by the ClusterSource of the CR.
+	cluster *Cluster
+	owner   *aws_sdk.ResourceOwner
+	// policyTemplates - the IAMPolicyTemplates referenced by the spec by their names.
+	policyTemplates map[string]*iamv1alpha1.IAMPolicyTemplate
+	// spec - copy of the AWSIAMProvision spec with the IAM names resolved by the naming scheme,
+	// it is used for all interactions with AWS IAM.
+	spec *iamv1alpha1.AWSIAMProvisionSpec
+	// statusChanged - the status was changed by updateCRDStatus and should be written by writeCRDStatus.
+	statusChanged bool
+	// templateData - the values of the document templates, they are collected by syncIAMResources.
+	templateData *documentTemplateData
+	// variables - the variables of the document templates merged from the spec and its variablesFrom sources.
+	variables map[string]string
 }
 
-type oidcProviderTemplateData struct {
-	OIDCProviderARN  string
-	OIDCProviderName string
+type iamNameTemplateData struct {
+	ClusterName string
+	Name        string
+	Namespace   string
 }
 
+// IAMClientFactory creates the AWS IAM client of a single reconciliation.
+type IAMClientFactory func(ctx context.Context, region, pathPrefix string, logger logr.Logger) (aws_sdk.IAMManager, error)
+
+// NewIAMClient is the default IAMClientFactory which uses the AWS SDK.
+func NewIAMClient(ctx context.Context, region, pathPrefix string, logger logr.Logger) (aws_sdk.IAMManager, error) {
+	iamClient, err := aws_sdk.NewIAMClient(ctx, region, pathPrefix, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return iamClient, nil
+}
+
+// ReconciliationManager holds the state of a single reconciliation.
+// A new instance is created for every request, so concurrent reconciliations of different CRs share only
+// the read-only configuration of the AWSIAMProvisionReconciler.
 type ReconciliationManager struct {
-	client.Client
+	*AWSIAMProvisionReconciler
 	ctx       context.Context
 	IAMClient aws_sdk.IAMManager
 	logger    logr.Logger
 	request   ctrl.Request
-	Scheme    *runtime.Scheme
 }
 
 func newAWSIAMResources() *awsIAMResources {
 	return &awsIAMResources{
-		awsIAMProvision: &iamv1alpha1.AWSIAMProvision{},
-		eksCP:           &ekscontrolplanev1.AWSManagedControlPlane{},
-		eksCPNamespace:  types.NamespacedName{},
+		awsIAMProvision:  &iamv1alpha1.AWSIAMProvision{},
+		conflictPolicies: make(map[string]struct{}),
+		conflictRoles:    make(map[string]struct{}),
+		cluster:          &Cluster{},
+		owner:            &aws_sdk.ResourceOwner{},
+		policyTemplates:  make(map[string]*iamv1alpha1.IAMPolicyTemplate),
 	}
 }
 
-func setFrequency(air *awsIAMResources) time.Duration {
-	if air != nil {
-		if air.awsIAMProvision.Spec.Frequency != nil {
-			return air.awsIAMProvision.Spec.Frequency.Duration
-		} else {
-			return time.Second * 30
+// setOwner sets the identity of the CR.
+func (air *awsIAMResources) setOwner() {
+	air.owner = &aws_sdk.ResourceOwner{
+		ClusterName: air.awsIAMProvision.Spec.EKSClusterName,
+		Name:        air.awsIAMProvision.Name,
+		Namespace:   air.awsIAMProvision.Namespace,
+		UID:         string(air.awsIAMProvision.UID),
+	}
+}
+
+// addConflict records the name of the IAM resource owned by another CR.
+func (air *awsIAMResources) addConflict(conflicts map[string]struct{}, name string) {
+	air.mu.Lock()
+	defer air.mu.Unlock()
+
+	conflicts[name] = struct{}{}
+}
+
+// hasConflict reports whether the IAM resource is owned by another CR.
+func (air *awsIAMResources) hasConflict(conflicts map[string]struct{}, name string) bool {
+	air.mu.Lock()
+	defer air.mu.Unlock()
+
+	_, ok := conflicts[name]
+
+	return ok
+}
+
+// declaresPolicy reports whether the spec of the CR declares the policy by the name.
+func (air *awsIAMResources) declaresPolicy(name string) bool {
+	for _, policy := range air.spec.Policies {
+		if *policy.Spec.Name == name {
+			return true
 		}
 	}
 
-	return frequency
+	return false
+}
+
+func setFrequency(air *awsIAMResources) time.Duration {
+	if air.awsIAMProvision.Spec.Frequency != nil {
+		return air.awsIAMProvision.Spec.Frequency.Duration
+	}
+
+	return time.Second * 30
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
 }
 
-func (rm *ReconciliationManager) getClusterResources() (*awsIAMResources, error) {
+// getClusterResources reads the CR and resolves its cluster, the CR is nil if it is not found or the cluster
+// is not available, then the reconciliation is requeued after the returned duration if it is set.
+// The cluster of the CR being deleted is not resolved.
+func (rm *ReconciliationManager) getClusterResources() (*awsIAMResources, time.Duration, error) {
 	air := newAWSIAMResources()
 	if err := rm.Get(rm.ctx, rm.request.NamespacedName, air.awsIAMProvision); err != nil {
 		if k8serrors.IsNotFound(err) {
 			rm.logger.Info(fmt.Sprintf("AWSIAMProvision not found: %s", rm.request.NamespacedName))
 
-			return nil, nil
+			return nil, 0, nil
 		}
 
-		return nil, err
+		return nil, 0, err
 	}
 
-	air.eksCPNamespace = types.NamespacedName{
-		Name:      air.awsIAMProvision.Spec.EKSClusterName,
-		Namespace: rm.request.NamespacedName.Namespace,
+	air.setOwner()
+
+	// The IAM resources are deleted by their names and the owner tags, neither the cluster nor the documents
+	// are needed. The cluster is usually deleted together with the CR, so it is not resolved.
+	if !air.awsIAMProvision.DeletionTimestamp.IsZero() {
+		if err := rm.resolveIAMResourcesSpec(air); err != nil {
+			rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+			if err := rm.writeCRDStatus(air); err != nil {
+				return nil, 0, err
+			}
+
+			return nil, 0, err
+		}
+
+		return air, 0, nil
 	}
-	if err := rm.Get(rm.ctx, air.eksCPNamespace, air.eksCP); err != nil {
-		if k8serrors.IsNotFound(err) {
-			msg := fmt.Sprintf("AWSManagedControlPlane of %s AWSIAMProvision not found: %s",
-				rm.request.NamespacedName, air.eksCPNamespace)
-			rm.logger.Info(msg)
-			if err := rm.updateCRDStatus(air, provisionIntermediatePhase, "", msg, nil); err != nil {
-				return nil, err
+
+	sourceType, clusterSource := rm.getClusterSource(air)
+	cluster, err := clusterSource.GetCluster(rm.ctx, air.awsIAMProvision)
+	if err != nil {
+		if !errors.Is(err, ErrClusterNotFound) && !errors.Is(err, ErrClusterNotReady) {
+			rm.setCondition(air, iamv1alpha1.ConditionTypeControlPlaneReady, metav1.ConditionUnknown,
+				iamv1alpha1.ConditionReasonControlPlaneNotFound, err.Error())
+			rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+			if err := rm.writeCRDStatus(air); err != nil {
+				return nil, 0, err
 			}
 
-			return nil, nil
+			return nil, 0, err
 		}
 
-		if err := rm.updateCRDStatus(air, failPhase, "", err.Error(), nil); err != nil {
-			return nil, err
+		reason := iamv1alpha1.ConditionReasonControlPlaneNotFound
+		if errors.Is(err, ErrClusterNotReady) {
+			reason = iamv1alpha1.ConditionReasonControlPlaneNotReady
 		}
 
-		return nil, err
+		rm.logger.Info(err.Error())
+		rm.setCondition(air, iamv1alpha1.ConditionTypeControlPlaneReady, metav1.ConditionFalse, reason, err.Error())
+		rm.updateCRDStatus(air, provisionIntermediatePhase, "", err.Error(), nil)
+
+		// The AWSManagedControlPlane is watched, the clusters of other sources are polled.
+		var requeueAfter time.Duration
+		if sourceType != iamv1alpha1.ClusterSourceCAPA {
+			requeueAfter = setFrequency(air)
+		}
+
+		return nil, requeueAfter, rm.writeCRDStatus(air)
+	}
+
+	air.cluster = cluster
+	rm.setCondition(air, iamv1alpha1.ConditionTypeControlPlaneReady, metav1.ConditionTrue,
+		iamv1alpha1.ConditionReasonControlPlaneReady, "")
+
+	err = rm.resolveIAMResourcesSpec(air)
+	if err == nil {
+		err = rm.resolveVariables(air)
+	}
+
+	if err == nil {
+		err = rm.resolvePolicyTemplates(air)
+	}
+
+	if err != nil {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonSyncFailed, err.Error())
+		rm.updateCRDStatus(air, failPhase, "", err.Error(), nil)
+		if err := rm.writeCRDStatus(air); err != nil {
+			return nil, 0, err
+		}
+
+		return nil, 0, err
+	}
+
+	return air, 0, nil
+}
+
+func (rm *ReconciliationManager) getIAMPathPrefix(air *awsIAMResources) string {
+	if air.awsIAMProvision.Spec.Path != nil {
+		return *air.awsIAMProvision.Spec.Path
+	}
+
+	return rm.IAMPathPrefix
+}
+
+func (rm *ReconciliationManager) getMode(air *awsIAMResources) string {
+	if len(air.awsIAMProvision.Spec.Mode) > 0 {
+		return air.awsIAMProvision.Spec.Mode
+	}
+
+	if len(rm.Mode) > 0 {
+		return rm.Mode
+	}
+
+	return iamv1alpha1.ModeEnforce
+}
+
+// planHash returns the hash of the plan observed in the Observe mode: the pending actions and the hash
+// of the rendered documents which the actions apply.
+func planHash(pendingActions []iamv1alpha1.AWSIAMProvisionPendingAction, documentsHash string) string {
+	hash := sha256.New()
+	for _, action := range pendingActions {
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s\x00", action.Action, action.ResourceType, action.Name, action.Role)
 	}
 
-	if !air.eksCP.Status.Ready {
-		msg := fmt.Sprintf("AWSManagedControlPlane of %s AWSIAMProvision not ready: %s",
-			rm.request.NamespacedName, air.eksCPNamespace)
+	fmt.Fprintf(hash, "%s\x00", documentsHash)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// acceptObservedPlan reports whether the plan recomputed in the Enforce mode is the plan observed in the Observe mode,
+// a different plan is not applied. The accepted plan is cleared, so the retries of a failed sync are not checked
+// against the partially applied plan.
+func (rm *ReconciliationManager) acceptObservedPlan(air *awsIAMResources,
+	pendingActions []iamv1alpha1.AWSIAMProvisionPendingAction, documentsHash string) bool {
+	if planHash(pendingActions, documentsHash) != air.awsIAMProvision.Status.PlanHash {
+		msg := fmt.Sprintf("The plan of %d actions differs from the plan observed in the Observe mode, "+
+			"it is not applied until it is observed again.", len(pendingActions))
 		rm.logger.Info(msg)
-		if err := rm.updateCRDStatus(air, provisionIntermediatePhase, "", msg, nil); err != nil {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeSynced, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonPlanChanged, msg)
+		rm.setReadyCondition(air)
+		rm.updateCRDStatus(air, failPhase, "", msg, nil)
+
+		return false
+	}
+
+	air.awsIAMProvision.Status.PendingActions = nil
+	air.awsIAMProvision.Status.PlanHash = ""
+	air.statusChanged = true
+
+	return true
+}
+
+// checkObservedPlan recomputes the plan of the CR switched from the Observe mode to the Enforce mode
+// without mutating AWS IAM and reports whether it can be applied. The reconciliation state of the CR is not changed.
+func (rm *ReconciliationManager) checkObservedPlan(air *awsIAMResources) (bool, error) {
+	if air.dryRun || len(air.awsIAMProvision.Status.PlanHash) == 0 {
+		return true, nil
+	}
+
+	planAIR := &awsIAMResources{
+		addons:           air.addons,
+		awsIAMProvision:  air.awsIAMProvision.DeepCopy(),
+		conflictPolicies: make(map[string]struct{}),
+		conflictRoles:    make(map[string]struct{}),
+		dryRun:           true,
+		cluster:          air.cluster,
+		owner:            air.owner,
+		policyTemplates:  air.policyTemplates,
+		spec:             air.spec,
+		variables:        air.variables,
+	}
+
+	dryRunIAMClient := aws_sdk.NewDryRunIAMManager(rm.IAMClient)
+	planRM := &ReconciliationManager{
+		AWSIAMProvisionReconciler: rm.AWSIAMProvisionReconciler,
+		ctx:                       rm.ctx,
+		IAMClient:                 dryRunIAMClient,
+		logger:                    rm.logger,
+		request:                   rm.request,
+	}
+
+	if err := planRM.syncIAMResources(planAIR); err != nil {
+		return false, err
+	}
+
+	return rm.acceptObservedPlan(air, newPendingActions(dryRunIAMClient.Actions()), planAIR.documentsHash), nil
+}
+
+// newPendingActions converts the actions recorded in the Observe mode to the pending actions of the status.
+func newPendingActions(actions []aws_sdk.Action) []iamv1alpha1.AWSIAMProvisionPendingAction {
+	var pendingActions []iamv1alpha1.AWSIAMProvisionPendingAction
+	for _, action := range actions {
+		pendingActions = append(pendingActions, iamv1alpha1.AWSIAMProvisionPendingAction{
+			Action:       action.Operation,
+			Name:         action.Name,
+			ResourceType: action.ResourceType,
+			Role:         action.RoleName,
+		})
+	}
+
+	return pendingActions
+}
+
+func (rm *ReconciliationManager) resolveIAMResourcesSpec(air *awsIAMResources) error {
+	air.spec = air.awsIAMProvision.Spec.DeepCopy()
+
+	nameTemplate := rm.IAMNameTemplate
+	if air.spec.NameTemplate != nil {
+		nameTemplate = *air.spec.NameTemplate
+	}
+
+	if err := rm.expandAddons(air, len(nameTemplate) > 0); err != nil {
+		return err
+	}
+
+	if len(nameTemplate) == 0 {
+		return nil
+	}
+
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return fmt.Errorf("name template of %s AWSIAMProvision malformed: %s", rm.request.NamespacedName, err)
+	}
+
+	renderName := func(name *string) (*string, error) {
+		if name == nil {
+			return nil, nil
+		}
+
+		var tmplString bytes.Buffer
+		if err := tmpl.Execute(&tmplString, &iamNameTemplateData{
+			ClusterName: air.spec.EKSClusterName,
+			Name:        *name,
+			Namespace:   air.awsIAMProvision.Namespace,
+		}); err != nil {
 			return nil, err
 		}
 
-		return nil, nil
+		result := tmplString.String()
+
+		return &result, nil
+	}
+
+	for key, role := range air.spec.Roles {
+		if role.Spec.Name, err = renderName(role.Spec.Name); err != nil {
+			return err
+		}
+
+		for num, rolePolicy := range role.Spec.Policies {
+			if role.Spec.Policies[num], err = renderName(rolePolicy); err != nil {
+				return err
+			}
+		}
+
+		air.spec.Roles[key] = role
+	}
+
+	for key, policy := range air.spec.Policies {
+		if policy.Spec.Name, err = renderName(policy.Spec.Name); err != nil {
+			return err
+		}
+
+		air.spec.Policies[key] = policy
 	}
 
-	return air, nil
+	return nil
 }
 
-func (rm *ReconciliationManager) deleteIAMResources(awsIAMProvision *iamv1alpha1.AWSIAMProvision) error {
-	for _, role := range awsIAMProvision.Spec.Roles {
-		_, exists, err := rm.IAMClient.GetRoleByName(role.Spec.Name)
+func (rm *ReconciliationManager) deleteIAMResources(air *awsIAMResources) error {
+	for _, role := range air.spec.Roles {
+		iamRole, exists, err := rm.IAMClient.GetRoleByName(role.Spec.Name)
 		if err != nil {
 			return err
 		}
 
-		if exists {
+		if exists && isManagedBy(air.owner, iamRole.Tags, true) {
 			policies, err := rm.IAMClient.ListAttachedRolePolicies(role.Spec.Name)
 			if err != nil {
 				return err
@@ -129,28 +438,71 @@ func (rm *ReconciliationManager) deleteIAMResources(awsIAMProvision *iamv1alpha1
 				return err
 			}
 
-			if err := rm.IAMClient.BatchDeletePolicies(policies); err != nil {
+			for _, policy := range policies {
+				rm.recordEvent(air, corev1.EventTypeNormal, detachPhase,
+					fmt.Sprintf("Policy %s was detached from role %s.", *policy.PolicyName, *role.Spec.Name))
+			}
+
+			// Only the policies of the CR are deleted, the policies of other CRs can be attached to the role as well.
+			var ownedPolicies []iamType.Policy
+			for _, policy := range policies {
+				if isManagedBy(air.owner, policy.Tags, air.declaresPolicy(*policy.PolicyName)) {
+					ownedPolicies = append(ownedPolicies, policy)
+				}
+			}
+
+			if err := rm.IAMClient.BatchDeletePolicies(ownedPolicies); err != nil {
 				return err
 			}
 
+			for _, policy := range ownedPolicies {
+				rm.recordEvent(air, corev1.EventTypeNormal, deletePhase,
+					fmt.Sprintf("Policy %s was deleted.", *policy.PolicyName))
+			}
+
 			if err := rm.IAMClient.DeleteRole(role.Spec.Name); err != nil {
 				return err
 			}
+
+			rm.recordEvent(air, corev1.EventTypeNormal, deletePhase, fmt.Sprintf("Role %s was deleted.", *role.Spec.Name))
 		}
 	}
 
-	return nil
-}
+	// Deleting the policies which were not attached to any role of the CR.
+	for _, policy := range air.spec.Policies {
+		iamPolicy, exists, err := rm.IAMClient.GetPolicyByName(policy.Spec.Name)
+		if err != nil {
+			return err
+		}
 
-func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error {
-	tags := aws_sdk.TagsDefine(air.awsIAMProvision.Spec.EKSClusterName, air.awsIAMProvision.Namespace)
+		if exists && isManagedBy(air.owner, iamPolicy.Tags, true) {
+			entities, err := rm.IAMClient.ListEntitiesForPolicy(iamPolicy)
+			if err != nil {
+				return err
+			}
 
-	iamRoles, err := rm.IAMClient.ListRolesByTags(tags)
-	if err != nil {
-		return err
+			for _, entity := range entities {
+				if err := rm.IAMClient.DetachRolePolicy(iamPolicy.PolicyName, entity.RoleName); err != nil {
+					return err
+				}
+
+				rm.recordEvent(air, corev1.EventTypeNormal, detachPhase,
+					fmt.Sprintf("Policy %s was detached from role %s.", *iamPolicy.PolicyName, *entity.RoleName))
+			}
+
+			if err := rm.IAMClient.DeletePolicy(iamPolicy.PolicyName); err != nil {
+				return err
+			}
+
+			rm.recordEvent(air, corev1.EventTypeNormal, deletePhase, fmt.Sprintf("Policy %s was deleted.", *iamPolicy.PolicyName))
+		}
 	}
 
-	iamPolicies, err := rm.IAMClient.ListPoliciesByTags(tags)
+	return nil
+}
+
+func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error {
+	iamRoles, iamPolicies, err := rm.listManagedResources(air)
 	if err != nil {
 		return err
 	}
@@ -160,23 +512,19 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 		deleteRoles[*iamRole.RoleName] = struct{}{}
 	}
 
-	for _, role := range air.awsIAMProvision.Spec.Roles {
+	attachedPolicies, err := rm.listAttachedPolicies(iamPolicies)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range air.spec.Roles {
 		if _, ok := deleteRoles[*role.Spec.Name]; ok {
 			delete(deleteRoles, *role.Spec.Name)
 		}
 
 		detachRolePolicies := make(map[string]struct{})
-		for _, iamPolicy := range iamPolicies {
-			entities, err := rm.IAMClient.ListEntitiesForPolicy(&iamPolicy)
-			if err != nil {
-				return err
-			}
-
-			for _, entity := range entities {
-				if *role.Spec.Name == *entity.RoleName {
-					detachRolePolicies[*iamPolicy.PolicyName] = struct{}{}
-				}
-			}
+		for _, policyName := range attachedPolicies[*role.Spec.Name] {
+			detachRolePolicies[policyName] = struct{}{}
 		}
 
 		for _, rolePolicy := range role.Spec.Policies {
@@ -190,11 +538,9 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 				return err
 			}
 
-			if err := rm.updateCRDStatus(air, provisionPhase, detachPhase,
+			rm.updateCRDStatus(air, provisionPhase, detachPhase,
 				fmt.Sprintf("Policy %s was detached from role %s.", detachRolePolicy, *role.Spec.Name),
-				&iamType.Policy{PolicyName: &detachRolePolicy}); err != nil {
-				return err
-			}
+				&iamType.Policy{PolicyName: &detachRolePolicy})
 		}
 	}
 
@@ -215,21 +561,17 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 					return err
 				}
 
-				if err := rm.updateCRDStatus(air, provisionPhase, detachPhase,
+				rm.updateCRDStatus(air, provisionPhase, detachPhase,
 					fmt.Sprintf("Policy %s was detached from role %s.", *policy.PolicyName, role),
-					&policy); err != nil {
-					return err
-				}
+					&policy)
 			}
 
 			if err := rm.IAMClient.DeleteRole(&role); err != nil {
 				return err
 			}
 
-			if err := rm.updateCRDStatus(air, provisionPhase, deletePhase,
-				fmt.Sprintf("Role %s was deleted.", *iamRole.RoleName), iamRole); err != nil {
-				return err
-			}
+			rm.updateCRDStatus(air, provisionPhase, deletePhase,
+				fmt.Sprintf("Role %s was deleted.", *iamRole.RoleName), iamRole)
 		}
 	}
 
@@ -238,7 +580,7 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 		deletePolicies[*iamPolicy.PolicyName] = struct{}{}
 	}
 
-	for _, policy := range air.awsIAMProvision.Spec.Policies {
+	for _, policy := range air.spec.Policies {
 		if _, ok := deletePolicies[*policy.Spec.Name]; ok {
 			delete(deletePolicies, *policy.Spec.Name)
 		}
@@ -261,10 +603,8 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 					return err
 				}
 
-				if err := rm.updateCRDStatus(air, provisionPhase, deletePhase,
-					fmt.Sprintf("Policy %s was deleted.", *policy.PolicyName), policy); err != nil {
-					return err
-				}
+				rm.updateCRDStatus(air, provisionPhase, deletePhase,
+					fmt.Sprintf("Policy %s was deleted.", *policy.PolicyName), policy)
 			}
 
 			for _, role := range entities {
@@ -277,10 +617,8 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 						return err
 					}
 
-					if err := rm.updateCRDStatus(air, provisionPhase, deletePhase,
-						fmt.Sprintf("Policy %s was deleted.", *policy.PolicyName), policy); err != nil {
-						return err
-					}
+					rm.updateCRDStatus(air, provisionPhase, deletePhase,
+						fmt.Sprintf("Policy %s was deleted.", *policy.PolicyName), policy)
 				}
 			}
 		}
@@ -289,93 +627,276 @@ func (rm *ReconciliationManager) syncAWSIAMResources(air *awsIAMResources) error
 	return nil
 }
 
-func (rm *ReconciliationManager) syncPoliciesByRoleSpec(air *awsIAMResources, role *iamv1alpha1.AWSIAMProvisionRole) error {
-	for _, rolePolicy := range role.Spec.Policies {
-		for _, policy := range air.awsIAMProvision.Spec.Policies {
-			// Coordination of the list `spec.role.spec.policies` with list `spec.policies`.
-			if *rolePolicy == *policy.Spec.Name {
-				iamPolicy, exists, err := rm.IAMClient.GetPolicyByName(policy.Spec.Name)
-				if err != nil {
+// recordSyncMetrics records the time of the successful sync of the CR and the number of its roles and policies
+// which exist in AWS IAM.
+func (rm *ReconciliationManager) recordSyncMetrics(air *awsIAMResources, roles, policies int) {
+	metrics.LastSuccessfulSync.WithLabelValues(air.awsIAMProvision.Namespace, air.awsIAMProvision.Name).
+		SetToCurrentTime()
+	rm.recordManagedResources(air, roles, policies)
+}
+
+// recordManagedResources records the number of the roles and policies of the CR which exist in AWS IAM.
+func (rm *ReconciliationManager) recordManagedResources(air *awsIAMResources, roles, policies int) {
+	clusterName := air.awsIAMProvision.Spec.EKSClusterName
+	namespace := air.awsIAMProvision.Namespace
+	name := air.awsIAMProvision.Name
+
+	metrics.ManagedResources.WithLabelValues(clusterName, namespace, name, metrics.ResourceTypeRole).
+		Set(float64(roles))
+	metrics.ManagedResources.WithLabelValues(clusterName, namespace, name, metrics.ResourceTypePolicy).
+		Set(float64(policies))
+}
+
+// hashDocuments returns the hash of the rendered documents of the roles and policies, the documents which fail
+// to render are hashed by the error, the error itself is reported by the sync of the resource.
+func (rm *ReconciliationManager) hashDocuments(air *awsIAMResources) string {
+	hash := sha256.New()
+	for _, key := range sortedMapKeys(air.spec.Roles) {
+		role := air.spec.Roles[key]
+		if err := rm.setAssumeRolePolicyDocument(air, &role); err != nil {
+			fmt.Fprintf(hash, "role\x00%s\x00%s\x00", *role.Spec.Name, err)
+			continue
+		}
+
+		fmt.Fprintf(hash, "role\x00%s\x00%s\x00", *role.Spec.Name, *role.Spec.AssumeRolePolicyDocument)
+	}
+
+	for _, key := range sortedMapKeys(air.spec.Policies) {
+		policy := air.spec.Policies[key]
+		if err := rm.setPolicyDocument(air, &policy); err != nil {
+			fmt.Fprintf(hash, "policy\x00%s\x00%s\x00", *policy.Spec.Name, err)
+			continue
+		}
+
+		fmt.Fprintf(hash, "policy\x00%s\x00%s\x00", *policy.Spec.Name, *policy.Spec.PolicyDocument)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// listManagedResources lists the roles and policies owned by the CR. The resources are listed by the CR identity tags,
+// the legacy owned resources are shared by all the CRs of the cluster in the namespace, so they are not listed
+// until they are adopted by the CR which declares them.
+func (rm *ReconciliationManager) listManagedResources(air *awsIAMResources) ([]iamType.Role, []iamType.Policy, error) {
+	tags := air.owner.IdentityTags()
+
+	iamRoles, err := rm.IAMClient.ListRolesByTags(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iamPolicies, err := rm.IAMClient.ListPoliciesByTags(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return iamRoles, iamPolicies, nil
+}
+
+// listAttachedPolicies lists the entities of every policy once and returns the names of the policies
+// by the names of the roles they are attached to.
+func (rm *ReconciliationManager) listAttachedPolicies(iamPolicies []iamType.Policy) (map[string][]string, error) {
+	var (
+		attached = make(map[string][]string)
+		mu       sync.Mutex
+		tasks    []func() error
+	)
+
+	for _, iamPolicy := range iamPolicies {
+		tasks = append(tasks, func() error {
+			entities, err := rm.IAMClient.ListEntitiesForPolicy(&iamPolicy)
+			if err != nil {
+				return fmt.Errorf("policy %s: %w", *iamPolicy.PolicyName, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, entity := range entities {
+				attached[*entity.RoleName] = append(attached[*entity.RoleName], *iamPolicy.PolicyName)
+			}
+
+			return nil
+		})
+	}
+
+	if errs := runParallel(rm.IAMWorkers, tasks); len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	return attached, nil
+}
+
+// syncIAMResources syncs the roles and policies of the spec with the remote state.
+// The policies are synced before the roles, so they can be attached to the roles. The independent roles and policies
+// are synced in parallel by IAMWorkers, the errors of all the roles and policies are aggregated.
+func (rm *ReconciliationManager) syncIAMResources(air *awsIAMResources) error {
+	air.templateData = newDocumentTemplateData(air, rm.IAMClient.GetIAMClientMetadata())
+	air.documentsHash = rm.hashDocuments(air)
+
+	if err := rm.syncAWSIAMResources(air); err != nil {
+		return err
+	}
+
+	errs := rm.syncPolicies(air)
+
+	var tasks []func() error
+	for _, role := range air.spec.Roles {
+		tasks = append(tasks, func() error {
+			if err := rm.syncRole(air, &role); err != nil {
+				return fmt.Errorf("role %s: %w", *role.Spec.Name, err)
+			}
+
+			return nil
+		})
+	}
+
+	return utilerrors.NewAggregate(append(errs, runParallel(rm.IAMWorkers, tasks)...))
+}
+
+func (rm *ReconciliationManager) syncPolicies(air *awsIAMResources) []error {
+	var tasks []func() error
+	for _, policy := range air.spec.Policies {
+		tasks = append(tasks, func() error {
+			if err := rm.syncPolicy(air, &policy); err != nil {
+				return fmt.Errorf("policy %s: %w", *policy.Spec.Name, err)
+			}
+
+			return nil
+		})
+	}
+
+	return runParallel(rm.IAMWorkers, tasks)
+}
+
+func (rm *ReconciliationManager) syncPolicy(air *awsIAMResources, policy *iamv1alpha1.AWSIAMProvisionPolicy) error {
+	if err := rm.setPolicyDocument(air, policy); err != nil {
+		return err
+	}
+
+	iamPolicy, exists, err := rm.IAMClient.GetPolicyByName(policy.Spec.Name)
+	if err != nil {
+		return err
+	}
+
+	checkSumTag := aws_sdk.NewChecksumTag(policy.Spec.PolicyDocument)
+	tags := aws_sdk.TagsDefine(air.owner, append(aws_sdk.ConvertToIAMTags(policy.Spec.Tags), checkSumTag)...)
+	description := fmt.Sprintf("%s%s. %s",
+		aws_sdk.PolicyDescriptionPrefix, air.awsIAMProvision.Spec.EKSClusterName, aws_sdk.IAMDescription)
+	// Creating policy if not created early, regardless of whether any role references it.
+	if !exists {
+		result, err := rm.IAMClient.CreatePolicy(policy.Spec.Name, policy.Spec.PolicyDocument, &description, tags)
+		if err != nil {
+			return err
+		}
+
+		if result == nil {
+			return nil
+		}
+
+		rm.updateCRDStatus(air, provisionPhase, createPhase,
+			fmt.Sprintf("Policy %s was created.", *policy.Spec.Name), result)
+
+		return nil
+	}
+
+	if managed, err := rm.checkPolicyOwnership(air, iamPolicy); err != nil || !managed {
+		return err
+	}
+
+	// Updating policy document if was changed
+	for _, tag := range iamPolicy.Tags {
+		if *tag.Key == aws_sdk.TagKeyPolicyDocument && *tag.Value != *checkSumTag.Value {
+			// The policy can be attached to several roles, all of them should be restored after recreation.
+			entities, err := rm.IAMClient.ListEntitiesForPolicy(iamPolicy)
+			if err != nil {
+				return err
+			}
+
+			for _, entity := range entities {
+				if err := rm.IAMClient.DetachRolePolicy(iamPolicy.PolicyName, entity.RoleName); err != nil {
 					return err
 				}
+			}
 
-				checkSumTag := aws_sdk.NewChecksumTag(policy.Spec.PolicyDocument)
-				tags := aws_sdk.TagsDefine(
-					air.awsIAMProvision.Spec.EKSClusterName,
-					air.awsIAMProvision.Namespace,
-					append(aws_sdk.ConvertToIAMTags(policy.Spec.Tags), checkSumTag)...,
-				)
-				description := fmt.Sprintf("%s%s. %s",
-					aws_sdk.PolicyDescriptionPrefix, air.awsIAMProvision.Spec.EKSClusterName, aws_sdk.IAMDescription)
-				// Creating and attaching policy if not created early.
-				if !exists {
-					result, err := rm.IAMClient.CreatePolicy(policy.Spec.Name, policy.Spec.PolicyDocument, &description, tags)
-					if err != nil {
-						return err
-					}
+			if err := rm.IAMClient.DeletePolicy(iamPolicy.PolicyName); err != nil {
+				return err
+			}
 
-					if err := rm.IAMClient.AttachRolePolicy(policy.Spec.Name, role.Spec.Name); err != nil {
-						return err
-					}
+			result, err := rm.IAMClient.CreatePolicy(policy.Spec.Name, policy.Spec.PolicyDocument, &description, tags)
+			if err != nil {
+				return err
+			}
 
-					if err := rm.updateCRDStatus(air, provisionPhase, createPhase+attachPhase,
-						fmt.Sprintf("Policy %s was created and attached to role %s.",
-							*policy.Spec.Name, *role.Spec.Name), result); err != nil {
-						return err
-					}
-				} else {
-					// Updating policy document if was changed
-					for _, tag := range iamPolicy.Tags {
-						if *tag.Key == aws_sdk.TagKeyPolicyDocument && *tag.Value != *checkSumTag.Value {
-							if err := rm.IAMClient.DetachRolePolicy(iamPolicy.PolicyName, role.Spec.Name); err != nil {
-								return err
-							}
-
-							if err := rm.IAMClient.DeletePolicy(iamPolicy.PolicyName); err != nil {
-								return err
-							}
-
-							_, err := rm.IAMClient.CreatePolicy(policy.Spec.Name, policy.Spec.PolicyDocument, &description, tags)
-							if err != nil {
-								return err
-							}
-
-							if err := rm.IAMClient.AttachRolePolicy(policy.Spec.Name, role.Spec.Name); err != nil {
-								return err
-							}
-
-							if err := rm.updateCRDStatus(air, provisionPhase, updatePhase,
-								fmt.Sprintf("Policy document for policy %s was updated.",
-									*policy.Spec.Name), iamPolicy); err != nil {
-								return err
-							}
-						}
-					}
+			if result == nil {
+				result = iamPolicy
+			}
 
-					// Sync attachment the policies by list of `spec.role.spec.policies`.
-					roleIAMPolicies, err := rm.IAMClient.ListAttachedRolePolicies(role.Spec.Name)
-					if err != nil {
-						return err
-					}
+			for _, entity := range entities {
+				if err := rm.IAMClient.AttachRolePolicy(policy.Spec.Name, entity.RoleName); err != nil {
+					return err
+				}
+			}
 
-					isAttachedToRole := make(map[string]struct{})
-					for _, roleIAMPolicy := range roleIAMPolicies {
-						isAttachedToRole[*roleIAMPolicy.PolicyName] = struct{}{}
-					}
+			rm.updateCRDStatus(air, provisionPhase, updatePhase,
+				fmt.Sprintf("Policy document for policy %s was updated.",
+					*policy.Spec.Name), result)
+		}
+	}
 
-					if _, ok := isAttachedToRole[*rolePolicy]; !ok {
-						if err := rm.IAMClient.AttachRolePolicy(policy.Spec.Name, role.Spec.Name); err != nil {
-							return err
-						}
+	return nil
+}
 
-						if err := rm.updateCRDStatus(air, provisionPhase, attachPhase,
-							fmt.Sprintf("Policy %s was attached to role %s.",
-								*policy.Spec.Name, *role.Spec.Name), iamPolicy); err != nil {
-							return err
-						}
-					}
-				}
+func (rm *ReconciliationManager) syncPoliciesByRoleSpec(air *awsIAMResources, role *iamv1alpha1.AWSIAMProvisionRole) error {
+	if air.hasConflict(air.conflictRoles, *role.Spec.Name) {
+		return nil
+	}
+
+	// Sync attachment the policies by list of `spec.role.spec.policies`.
+	roleIAMPolicies, err := rm.IAMClient.ListAttachedRolePolicies(role.Spec.Name)
+	if err != nil {
+		return err
+	}
+
+	isAttachedToRole := make(map[string]struct{})
+	for _, roleIAMPolicy := range roleIAMPolicies {
+		isAttachedToRole[*roleIAMPolicy.PolicyName] = struct{}{}
+	}
+
+	for _, rolePolicy := range role.Spec.Policies {
+		for _, policy := range air.spec.Policies {
+			// Coordination of the list `spec.role.spec.policies` with list `spec.policies`.
+			if *rolePolicy != *policy.Spec.Name {
+				continue
+			}
+
+			if air.hasConflict(air.conflictPolicies, *rolePolicy) {
+				continue
+			}
+
+			if _, ok := isAttachedToRole[*rolePolicy]; ok {
+				continue
+			}
+
+			// Policies are provisioned by syncPolicies before the roles, so the policy is expected to exist.
+			iamPolicy, exists, err := rm.IAMClient.GetPolicyByName(policy.Spec.Name)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				continue
+			}
+
+			if err := rm.IAMClient.AttachRolePolicy(policy.Spec.Name, role.Spec.Name); err != nil {
+				return err
 			}
+
+			isAttachedToRole[*rolePolicy] = struct{}{}
+
+			rm.updateCRDStatus(air, provisionPhase, attachPhase,
+				fmt.Sprintf("Policy %s was attached to role %s.",
+					*policy.Spec.Name, *role.Spec.Name), iamPolicy)
 		}
 	}
 
@@ -383,10 +904,7 @@ func (rm *ReconciliationManager) syncPoliciesByRoleSpec(air *awsIAMResources, ro
 }
 
 func (rm *ReconciliationManager) syncRole(air *awsIAMResources, role *iamv1alpha1.AWSIAMProvisionRole) error {
-	tags := aws_sdk.TagsDefine(
-		air.awsIAMProvision.Spec.EKSClusterName,
-		air.awsIAMProvision.Namespace,
-		aws_sdk.ConvertToIAMTags(role.Spec.Tags)...)
+	tags := aws_sdk.TagsDefine(air.owner, aws_sdk.ConvertToIAMTags(role.Spec.Tags)...)
 
 	if err := rm.setAssumeRolePolicyDocument(air, role); err != nil {
 		return err
@@ -405,15 +923,17 @@ func (rm *ReconciliationManager) syncRole(air *awsIAMResources, role *iamv1alpha
 			return err
 		}
 
-		if err := rm.updateCRDStatus(air, provisionPhase, createPhase,
-			fmt.Sprintf("Role %s was created.", *role.Spec.Name), result); err != nil {
-			return err
-		}
+		rm.updateCRDStatus(air, provisionPhase, createPhase,
+			fmt.Sprintf("Role %s was created.", *role.Spec.Name), result)
 
 		if err := rm.syncPoliciesByRoleSpec(air, role); err != nil {
 			return err
 		}
 	} else {
+		if managed, err := rm.checkRoleOwnership(air, iamRole); err != nil || !managed {
+			return err
+		}
+
 		diff, err := rm.IAMClient.DiffRoleByPolicyDocument(iamRole.AssumeRolePolicyDocument, role.Spec.AssumeRolePolicyDocument)
 		if err != nil {
 			return err
@@ -424,11 +944,9 @@ func (rm *ReconciliationManager) syncRole(air *awsIAMResources, role *iamv1alpha
 				return err
 			}
 
-			if err := rm.updateCRDStatus(air, provisionPhase, updatePhase,
+			rm.updateCRDStatus(air, provisionPhase, updatePhase,
 				fmt.Sprintf("The trust relationship policy document for role %s was updated.",
-					*role.Spec.Name), iamRole); err != nil {
-				return err
-			}
+					*role.Spec.Name), iamRole)
 		}
 
 		if err := rm.syncPoliciesByRoleSpec(air, role); err != nil {
@@ -439,45 +957,143 @@ func (rm *ReconciliationManager) syncRole(air *awsIAMResources, role *iamv1alpha
 	return nil
 }
 
-func (rm *ReconciliationManager) setAssumeRolePolicyDocument(air *awsIAMResources, role *iamv1alpha1.AWSIAMProvisionRole) error {
-	var oidcProviderARNFound bool
+// isManagedBy reports whether the IAM resource with the tags can be managed by the CR. The legacy owned resources
+// are shared by all the CRs of the cluster in the namespace, so they are managed only if the CR declares them.
+func isManagedBy(owner *aws_sdk.ResourceOwner, tags []iamType.Tag, declared bool) bool {
+	switch owner.GetOwnership(tags) {
+	case aws_sdk.Owned:
+		return true
+	case aws_sdk.LegacyOwned:
+		return declared
+	default:
+		return false
+	}
+}
 
-	oidcPr := &oidcProviderTemplateData{OIDCProviderARN: air.eksCP.Status.OIDCProvider.ARN}
-	_, oidcPr.OIDCProviderName, oidcProviderARNFound = strings.Cut(oidcPr.OIDCProviderARN, "/")
+// checkPolicyOwnership verifies that the existing policy can be managed by the CR.
+// A legacy owned policy is adopted by adding the CR identity tags,
+// a policy owned by another CR or created not by the operator is recorded as a conflict.
+func (rm *ReconciliationManager) checkPolicyOwnership(air *awsIAMResources, iamPolicy *iamType.Policy) (bool, error) {
+	switch air.owner.GetOwnership(iamPolicy.Tags) {
+	case aws_sdk.Owned:
+		return true, nil
+	case aws_sdk.LegacyOwned:
+		if err := rm.IAMClient.TagPolicy(iamPolicy.PolicyName, air.owner.IdentityTags()); err != nil {
+			return false, err
+		}
 
-	if !oidcProviderARNFound {
-		err := fmt.Errorf("OIDC ARN of %s AWSManagedControlPlane of %s AWSIAMProvision malformed: %s",
-			air.eksCPNamespace, rm.request.NamespacedName, oidcPr.OIDCProviderARN)
-		if err := rm.updateCRDStatus(air, failPhase, "", err.Error(), nil); err != nil {
-			return err
+		rm.recordEvent(air, corev1.EventTypeNormal, adoptPhase,
+			fmt.Sprintf("Policy %s created by the previous version of the operator was adopted.", *iamPolicy.PolicyName))
+
+		return true, nil
+	default:
+		air.addConflict(air.conflictPolicies, *iamPolicy.PolicyName)
+		msg := fmt.Sprintf("Policy %s is owned by another AWSIAMProvision or was not created by the operator.",
+			*iamPolicy.PolicyName)
+		rm.logger.Info(msg)
+
+		rm.updateCRDStatus(air, failPhase, conflictPhase, msg, iamPolicy)
+
+		return false, nil
+	}
+}
+
+// checkRoleOwnership verifies that the existing role can be managed by the CR.
+// A legacy owned role is adopted by adding the CR identity tags,
+// a role owned by another CR or created not by the operator is recorded as a conflict.
+func (rm *ReconciliationManager) checkRoleOwnership(air *awsIAMResources, iamRole *iamType.Role) (bool, error) {
+	switch air.owner.GetOwnership(iamRole.Tags) {
+	case aws_sdk.Owned:
+		return true, nil
+	case aws_sdk.LegacyOwned:
+		if err := rm.IAMClient.TagRole(iamRole.RoleName, air.owner.IdentityTags()); err != nil {
+			return false, err
 		}
 
-		return err
+		rm.recordEvent(air, corev1.EventTypeNormal, adoptPhase,
+			fmt.Sprintf("Role %s created by the previous version of the operator was adopted.", *iamRole.RoleName))
+
+		return true, nil
+	default:
+		air.addConflict(air.conflictRoles, *iamRole.RoleName)
+		msg := fmt.Sprintf("Role %s is owned by another AWSIAMProvision or was not created by the operator.",
+			*iamRole.RoleName)
+		rm.logger.Info(msg)
+
+		rm.updateCRDStatus(air, failPhase, conflictPhase, msg, iamRole)
+
+		return false, nil
 	}
+}
 
-	if assumeRolePolicyDocument, err := rm.renderOIDCProviderTemplate(*role.Spec.AssumeRolePolicyDocument, oidcPr); err != nil {
-		if err := rm.updateCRDStatus(air, failPhase, "", err.Error(), nil); err != nil {
-			return err
+func (rm *ReconciliationManager) setAssumeRolePolicyDocument(air *awsIAMResources, role *iamv1alpha1.AWSIAMProvisionRole) error {
+	// The structured trust without the ServiceAccounts does not refer to the OIDC provider.
+	if (role.Spec.Trust == nil || len(role.Spec.Trust.ServiceAccounts) > 0) && len(air.templateData.OIDCProviderName) == 0 {
+		return fmt.Errorf("OIDC ARN of the cluster of %s AWSIAMProvision malformed: %s",
+			rm.request.NamespacedName, air.templateData.OIDCProviderARN)
+	}
+
+	if role.Spec.TrustTemplateRef != nil {
+		assumeRolePolicyDocument, err := renderPolicyTemplate(air, role.Spec.TrustTemplateRef)
+		if err != nil {
+			return fmt.Errorf("trust relationship policy template malformed: %w", err)
 		}
 
-		return err
-	} else {
 		role.Spec.AssumeRolePolicyDocument = &assumeRolePolicyDocument
 
 		return nil
 	}
-}
 
-func (rm *ReconciliationManager) renderOIDCProviderTemplate(assumeRolePolicyDocument string, templateData *oidcProviderTemplateData) (string, error) {
-	tmpl, err := template.New("").Parse(assumeRolePolicyDocument)
+	if role.Spec.Trust != nil {
+		assumeRolePolicyDocument, err := compileTrustDocument(role.Spec.Trust,
+			string(air.templateData.OIDCProviderARN), string(air.templateData.OIDCProviderName))
+		if err != nil {
+			return fmt.Errorf("trust relationship policy malformed: %w", err)
+		}
+
+		role.Spec.AssumeRolePolicyDocument = &assumeRolePolicyDocument
+
+		return nil
+	}
+
+	// The failure is reported to the status by the caller together with the errors of other roles.
+	assumeRolePolicyDocument, err := renderDocumentTemplate(*role.Spec.AssumeRolePolicyDocument, air.templateData)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("trust relationship policy document template malformed: %w", err)
+	}
+
+	role.Spec.AssumeRolePolicyDocument = &assumeRolePolicyDocument
+
+	return nil
+}
+
+func (rm *ReconciliationManager) setPolicyDocument(air *awsIAMResources, policy *iamv1alpha1.AWSIAMProvisionPolicy) error {
+	if policy.Spec.TemplateRef != nil {
+		policyDocument, err := renderPolicyTemplate(air, policy.Spec.TemplateRef)
+		if err != nil {
+			return fmt.Errorf("policy template malformed: %w", err)
+		}
+
+		policy.Spec.PolicyDocument = &policyDocument
+
+		return nil
+	}
+
+	if len(policy.Spec.Statements) > 0 {
+		document, err := compilePolicyStatements(policy.Spec.Statements)
+		if err != nil {
+			return fmt.Errorf("policy statements malformed: %w", err)
+		}
+
+		policy.Spec.PolicyDocument = &document
 	}
 
-	var tmplString bytes.Buffer
-	if err := tmpl.Execute(&tmplString, templateData); err != nil {
-		return "", err
+	policyDocument, err := renderDocumentTemplate(*policy.Spec.PolicyDocument, air.templateData)
+	if err != nil {
+		return fmt.Errorf("policy document template malformed: %w", err)
 	}
 
-	return tmplString.String(), nil
+	policy.Spec.PolicyDocument = &policyDocument
+
+	return nil
 }
@@ -0,0 +1,328 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestReconcileOwnership checks the IAM resources are synced and cleaned up by their ownership.
+func TestReconcileOwnership(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	owner := &aws_sdk.ResourceOwner{ClusterName: clusterName, Name: air.Name, Namespace: air.Namespace, UID: string(air.UID)}
+	other := &aws_sdk.ResourceOwner{ClusterName: clusterName, Name: "other", Namespace: air.Namespace, UID: "other-uid"}
+
+	iamManager := newFakeIAMManager()
+	document := aws.String(`{"Version":"2012-10-17","Statement":[]}`)
+	for roleName, tags := range map[string][]iamType.Tag{
+		// LegacyOwned and in the spec, adopted.
+		clusterName + "-reader": owner.LegacyTags(),
+		// OwnedByOther and in the spec, a conflict.
+		clusterName + "-role": aws_sdk.TagsDefine(other),
+		// LegacyOwned and not in the spec, it can be declared by another CR of the cluster in the namespace, kept.
+		clusterName + "-legacy": owner.LegacyTags(),
+		// OwnedByOther, kept.
+		clusterName + "-other": aws_sdk.TagsDefine(other),
+		// NotOwned, kept.
+		clusterName + "-manual": nil,
+	} {
+		if _, err := iamManager.CreateRole(aws.String(roleName), document, nil, tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := iamManager.CreatePolicy(aws.String(clusterName+"-legacy"), document, nil, owner.LegacyTags()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestReconciler(t, iamManager, objects...)
+	key := testKey(clusterName)
+	mustReconcile(t, r, key)
+
+	for roleName, ownership := range map[string]aws_sdk.Ownership{
+		clusterName + "-reader": aws_sdk.Owned,
+		clusterName + "-role":   aws_sdk.OwnedByOther,
+		clusterName + "-legacy": aws_sdk.LegacyOwned,
+		clusterName + "-other":  aws_sdk.OwnedByOther,
+		clusterName + "-manual": aws_sdk.NotOwned,
+	} {
+		role, exists, _ := iamManager.GetRoleByName(aws.String(roleName))
+		if !exists {
+			t.Errorf("role %s deleted", roleName)
+			continue
+		}
+
+		if got := owner.GetOwnership(role.Tags); got != ownership {
+			t.Errorf("role %s: expected ownership %d, got %d", roleName, ownership, got)
+		}
+	}
+
+	if _, exists, _ := iamManager.GetPolicyByName(aws.String(clusterName + "-legacy")); !exists {
+		t.Error("legacy owned policy not in the spec deleted")
+	}
+
+	if _, ok := iamManager.attached[clusterName+"-role"]; ok {
+		t.Error("policy attached to the role owned by another CR")
+	}
+
+	mustGet(t, r, key, air)
+	for _, roleStatus := range air.Status.Roles {
+		synced := meta.FindStatusCondition(roleStatus.Conditions, iamv1alpha1.ConditionTypeSynced)
+		if synced == nil {
+			t.Errorf("role %s: Synced condition not set", *roleStatus.Name)
+			continue
+		}
+
+		conflict := *roleStatus.Name == clusterName+"-role"
+		if got := synced.Reason == iamv1alpha1.ConditionReasonConflict; got != conflict {
+			t.Errorf("role %s: unexpected Synced condition %s: %s", *roleStatus.Name, synced.Reason, synced.Message)
+		}
+
+		if conflict && synced.Status != metav1.ConditionFalse {
+			t.Errorf("role %s: conflict expected not synced", *roleStatus.Name)
+		}
+	}
+
+	// The adopted role is deleted as an owned one once it is removed from the spec.
+	delete(air.Spec.Roles, "reader")
+	if err := r.Update(context.Background(), air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if _, exists, _ := iamManager.GetRoleByName(aws.String(clusterName + "-reader")); exists {
+		t.Error("owned role removed from the spec not deleted")
+	}
+
+	for _, roleName := range []string{clusterName + "-role", clusterName + "-legacy", clusterName + "-other", clusterName + "-manual"} {
+		if _, exists, _ := iamManager.GetRoleByName(aws.String(roleName)); !exists {
+			t.Errorf("role %s not owned by the CR deleted", roleName)
+		}
+	}
+}
+
+// TestReconcileSharedLegacyTags checks the CRs of the same cluster in the same namespace adopt only the legacy owned
+// resources they declare and never clean up the legacy owned resources of each other.
+func TestReconcileSharedLegacyTags(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	objects := newTestObjects(clusterName)
+	first := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	second := first.DeepCopy()
+	second.Name = "second"
+	second.UID = "second-uid"
+	second.Spec.Roles = map[string]iamv1alpha1.AWSIAMProvisionRole{"role": {Spec: iamv1alpha1.RoleSpec{
+		Name:     aws.String(clusterName + "-second-role"),
+		Policies: []*string{aws.String(clusterName + "-second-policy")},
+		Trust:    newTestTrust("default", "second"),
+	}}}
+	second.Spec.Policies = map[string]iamv1alpha1.AWSIAMProvisionPolicy{"policy": {Spec: iamv1alpha1.PolicySpec{
+		Name:           aws.String(clusterName + "-second-policy"),
+		PolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[]}`),
+	}}}
+	objects = append(objects, second)
+
+	// The resources of both CRs were created by the previous version of the operator.
+	legacy := (&aws_sdk.ResourceOwner{ClusterName: clusterName, Namespace: first.Namespace}).LegacyTags()
+	iamManager := newFakeIAMManager()
+	document := aws.String(`{"Version":"2012-10-17","Statement":[]}`)
+	for _, roleName := range []string{clusterName + "-role", clusterName + "-second-role"} {
+		if _, err := iamManager.CreateRole(aws.String(roleName), document, nil, legacy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, policyName := range []string{clusterName + "-policy", clusterName + "-second-policy"} {
+		if _, err := iamManager.CreatePolicy(aws.String(policyName), document, nil, legacy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestReconciler(t, iamManager, objects...)
+	mustReconcile(t, r, testKey(clusterName))
+
+	for _, roleName := range []string{clusterName + "-role", clusterName + "-second-role"} {
+		if _, exists, _ := iamManager.GetRoleByName(aws.String(roleName)); !exists {
+			t.Fatalf("role %s deleted by the first CR", roleName)
+		}
+	}
+
+	for _, policyName := range []string{clusterName + "-policy", clusterName + "-second-policy"} {
+		if _, exists, _ := iamManager.GetPolicyByName(aws.String(policyName)); !exists {
+			t.Fatalf("policy %s deleted by the first CR", policyName)
+		}
+	}
+
+	mustReconcile(t, r, client.ObjectKeyFromObject(second))
+
+	for resourceName, owner := range map[string]*iamv1alpha1.AWSIAMProvision{
+		clusterName + "-role":          first,
+		clusterName + "-policy":        first,
+		clusterName + "-second-role":   second,
+		clusterName + "-second-policy": second,
+	} {
+		var tags []iamType.Tag
+		if role, exists, _ := iamManager.GetRoleByName(aws.String(resourceName)); exists {
+			tags = role.Tags
+		} else if policy, exists, _ := iamManager.GetPolicyByName(aws.String(resourceName)); exists {
+			tags = policy.Tags
+		} else {
+			t.Errorf("%s deleted", resourceName)
+			continue
+		}
+
+		resourceOwner := &aws_sdk.ResourceOwner{ClusterName: clusterName, Name: owner.Name, Namespace: owner.Namespace,
+			UID: string(owner.UID)}
+		if got := resourceOwner.GetOwnership(tags); got != aws_sdk.Owned {
+			t.Errorf("%s: expected to be adopted by %s, got ownership %d", resourceName, owner.Name, got)
+		}
+	}
+}
+
+// TestReconcileAttachmentCalls checks the attachments of every role and every policy are listed once per reconciliation.
+func TestReconcileAttachmentCalls(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, newTestObjects(clusterName)...)
+	key := testKey(clusterName)
+	mustReconcile(t, r, key)
+
+	// The resync lists the attachments of the existing policy.
+	iamManager.calls = make(map[string]int)
+	mustReconcile(t, r, key)
+
+	for method, expected := range map[string]int{"ListAttachedRolePolicies": 2, "ListEntitiesForPolicy": 1} {
+		if got := iamManager.calls[method]; got != expected {
+			t.Errorf("%s: expected %d calls, got %d", method, expected, got)
+		}
+	}
+}
+
+// TestReconcileStandalonePolicies checks the policies not referenced by any role are provisioned and cleaned up.
+func TestReconcileStandalonePolicies(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	air.Spec.Policies["standalone"] = iamv1alpha1.AWSIAMProvisionPolicy{Spec: iamv1alpha1.PolicySpec{
+		Name:           aws.String(clusterName + "-standalone"),
+		PolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`),
+	}}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+	key := testKey(clusterName)
+	mustReconcile(t, r, key)
+
+	policy, exists, _ := iamManager.GetPolicyByName(aws.String(clusterName + "-standalone"))
+	if !exists {
+		t.Fatal("standalone policy not provisioned")
+	}
+
+	if entities, _ := iamManager.ListEntitiesForPolicy(policy); len(entities) > 0 {
+		t.Errorf("standalone policy attached to %v", entities)
+	}
+
+	mustGet(t, r, key, air)
+	if len(air.Status.Policies) != 2 {
+		t.Errorf("standalone policy not in status: %v", air.Status.Policies)
+	}
+
+	delete(air.Spec.Policies, "standalone")
+	if err := r.Update(context.Background(), air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if _, exists, _ := iamManager.GetPolicyByName(aws.String(clusterName + "-standalone")); exists {
+		t.Error("standalone policy removed from the spec not deleted")
+	}
+
+	if _, exists, _ := iamManager.GetPolicyByName(aws.String(clusterName + "-policy")); !exists {
+		t.Error("policy of the roles deleted")
+	}
+}
+
+// TestReconcilePathAndNameTemplate checks the IAM path and the naming scheme of the operator
+// and their overrides by the CR.
+func TestReconcilePathAndNameTemplate(t *testing.T) {
+	objects := append(newTestObjects("operator"), newTestObjects("override")...)
+	override := objects[3].(*iamv1alpha1.AWSIAMProvision)
+	override.Spec.Path = aws.String("/production/")
+	override.Spec.NameTemplate = aws.String("prod-{{ .Name }}")
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, objects...)
+	r.IAMNameTemplate = "{{ .Namespace }}-{{ .Name }}"
+	r.IAMPathPrefix = "/staging/"
+
+	var pathPrefixes []string
+	r.NewIAMClient = func(_ context.Context, _, pathPrefix string, _ logr.Logger) (aws_sdk.IAMManager, error) {
+		pathPrefixes = append(pathPrefixes, pathPrefix)
+		return iamManager, nil
+	}
+
+	mustReconcile(t, r, testKey("operator"))
+	mustReconcile(t, r, testKey("override"))
+
+	if expected := []string{"/staging/", "/production/"}; !reflect.DeepEqual(pathPrefixes, expected) {
+		t.Errorf("expected IAM paths %v, got %v", expected, pathPrefixes)
+	}
+
+	for roleName, policyName := range map[string]string{
+		testNamespace + "-operator-role": testNamespace + "-operator-policy",
+		"prod-override-role":             "prod-override-policy",
+	} {
+		if _, exists, _ := iamManager.GetRoleByName(aws.String(roleName)); !exists {
+			t.Errorf("role %s not provisioned, roles: %v", roleName, iamManager.roles)
+		}
+
+		if _, ok := iamManager.attached[roleName][policyName]; !ok {
+			t.Errorf("policy reference of role %s not rendered, attached: %v", roleName, iamManager.attached[roleName])
+		}
+	}
+
+	// The malformed naming scheme fails the CR before AWS IAM is touched.
+	mustGet(t, r, testKey("override"), override)
+	override.Spec.NameTemplate = aws.String("{{ .Unknown }}")
+	if err := r.Update(context.Background(), override); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: testKey("override")}); err == nil {
+		t.Error("malformed name template expected to fail")
+	}
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// Fake values of the environment used by Render when the values are not supplied.
+const (
+	FakeAccountID = "123456789012"
+	FakeOIDCID    = "EXAMPLED539D4633E53DE1B71EXAMPLE"
+	FakeRegion    = "us-east-1"
+)
+
+// RenderValues - the values of the environment which the documents are rendered for.
+type RenderValues struct {
+	AccountID       string `json:"accountID,omitempty"`
+	OIDCProviderARN string `json:"oidcProviderARN,omitempty"`
+	Region          string `json:"region,omitempty"`
+	// Variables - the data of the variablesFrom sources of the CR, the sources are not read.
+	Variables map[string]string `json:"variables,omitempty"`
+	// PolicyTemplates - the IAMPolicyTemplates referenced by the CR, they are taken from the manifest.
+	PolicyTemplates []iamv1alpha1.IAMPolicyTemplate `json:"-"`
+}
+
+// RenderedDocument - the rendered trust relationship policy document of a role or the document of a policy.
+type RenderedDocument struct {
+	Document string
+	// Key - the key of the role or policy in the spec of the CR.
+	Key          string
+	Name         string
+	ResourceType string
+}
+
+// regionPartition returns the AWS partition of the region, it is used only to fake the OIDC provider
+// of the environment.
+func regionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// newOfflineReconciliationManager creates the reconciliation state of the CR which is not read from the cluster.
+func (r *AWSIAMProvisionReconciler) newOfflineReconciliationManager(ctx context.Context,
+	awsIAMProvision *iamv1alpha1.AWSIAMProvision) (*ReconciliationManager, *awsIAMResources) {
+	rm := &ReconciliationManager{
+		AWSIAMProvisionReconciler: r,
+		ctx:                       ctx,
+		logger:                    log.FromContext(ctx),
+		request:                   ctrl.Request{NamespacedName: client.ObjectKeyFromObject(awsIAMProvision)},
+	}
+
+	air := newAWSIAMResources()
+	air.awsIAMProvision = awsIAMProvision.DeepCopy()
+	air.dryRun = true
+	air.setOwner()
+
+	return rm, air
+}
+
+// Render renders the documents of all the roles and policies of the CR without access to the cluster and AWS,
+// the values which are not supplied are faked. Every document is validated to be JSON,
+// the errors of all the documents are aggregated.
+func (r *AWSIAMProvisionReconciler) Render(ctx context.Context, awsIAMProvision *iamv1alpha1.AWSIAMProvision,
+	values RenderValues) ([]RenderedDocument, error) {
+	rm, air := r.newOfflineReconciliationManager(ctx, awsIAMProvision)
+
+	if len(values.AccountID) == 0 {
+		values.AccountID = FakeAccountID
+	}
+
+	if len(values.Region) == 0 {
+		values.Region = air.awsIAMProvision.Spec.Region
+	}
+
+	if len(values.Region) == 0 {
+		values.Region = FakeRegion
+	}
+
+	if len(values.OIDCProviderARN) == 0 {
+		values.OIDCProviderARN = fmt.Sprintf("arn:%s:iam::%s:oidc-provider/oidc.eks.%s.amazonaws.com/id/%s",
+			regionPartition(values.Region), values.AccountID, values.Region, FakeOIDCID)
+	}
+
+	air.cluster = &Cluster{OIDCProviderARN: values.OIDCProviderARN}
+
+	if err := rm.resolveIAMResourcesSpec(air); err != nil {
+		return nil, err
+	}
+
+	air.variables = mergeVariables(values.Variables, air.awsIAMProvision.Spec.Variables)
+	for i := range values.PolicyTemplates {
+		air.policyTemplates[values.PolicyTemplates[i].Name] = &values.PolicyTemplates[i]
+	}
+
+	if err := rm.resolvePolicyTemplates(air); err != nil {
+		return nil, err
+	}
+
+	pathPrefix := rm.getIAMPathPrefix(air)
+	if len(pathPrefix) == 0 {
+		pathPrefix = aws_sdk.DefaultPathPrefix
+	}
+
+	// The documents are rendered in the partition of the supplied or faked OIDC provider.
+	var partition string
+	if oidcProviderARN, err := arn.Parse(values.OIDCProviderARN); err == nil {
+		partition = oidcProviderARN.Partition
+	}
+
+	air.templateData = newDocumentTemplateData(air, &aws_sdk.IAMClientMetadata{
+		AccountID:  values.AccountID,
+		Partition:  partition,
+		PathPrefix: pathPrefix,
+		Region:     values.Region,
+	})
+
+	var (
+		documents []RenderedDocument
+		errs      []error
+	)
+
+	for _, key := range sortedMapKeys(air.spec.Roles) {
+		role := air.spec.Roles[key]
+		if err := rm.setAssumeRolePolicyDocument(air, &role); err != nil {
+			errs = append(errs, fmt.Errorf("role %s: %w", key, err))
+			continue
+		}
+
+		if !json.Valid([]byte(*role.Spec.AssumeRolePolicyDocument)) {
+			errs = append(errs, fmt.Errorf("role %s: trust relationship policy document is not valid JSON", key))
+			continue
+		}
+
+		documents = append(documents, RenderedDocument{
+			Document:     *role.Spec.AssumeRolePolicyDocument,
+			Key:          key,
+			Name:         *role.Spec.Name,
+			ResourceType: aws_sdk.ResourceTypeRole,
+		})
+	}
+
+	for _, key := range sortedMapKeys(air.spec.Policies) {
+		policy := air.spec.Policies[key]
+		if err := rm.setPolicyDocument(air, &policy); err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", key, err))
+			continue
+		}
+
+		if !json.Valid([]byte(*policy.Spec.PolicyDocument)) {
+			errs = append(errs, fmt.Errorf("policy %s: policy document is not valid JSON", key))
+			continue
+		}
+
+		documents = append(documents, RenderedDocument{
+			Document:     *policy.Spec.PolicyDocument,
+			Key:          key,
+			Name:         *policy.Spec.Name,
+			ResourceType: aws_sdk.ResourceTypePolicy,
+		})
+	}
+
+	return documents, utilerrors.NewAggregate(errs)
+}
+
+func sortedMapKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestRender renders the documents with the supplied and faked values and reports the invalid documents.
+func TestRender(t *testing.T) {
+	const clusterName = "rendered"
+
+	ctx := context.Background()
+	air := newTestObjects(clusterName)[1].(*iamv1alpha1.AWSIAMProvision)
+	r := &AWSIAMProvisionReconciler{}
+
+	documents, err := r.Render(ctx, air, RenderValues{OIDCProviderARN: oidcProviderARN(clusterName)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, document := range documents {
+		names = append(names, document.ResourceType+"/"+document.Name)
+		if document.ResourceType == aws_sdk.ResourceTypeRole && !strings.Contains(document.Document, oidcProviderARN(clusterName)) {
+			t.Errorf("role %s: OIDC provider ARN is not rendered: %s", document.Name, document.Document)
+		}
+	}
+
+	expected := []string{"Role/rendered-reader", "Role/rendered-role", "Policy/rendered-policy"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected documents %v, expected %v", names, expected)
+	}
+
+	documents, err = r.Render(ctx, air, RenderValues{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeARN := fmt.Sprintf("arn:aws:iam::%s:oidc-provider/oidc.eks.%s.amazonaws.com/id/%s", FakeAccountID, testRegion, FakeOIDCID)
+	if !strings.Contains(documents[0].Document, fakeARN) {
+		t.Errorf("fake OIDC provider ARN is not rendered: %s", documents[0].Document)
+	}
+
+	policy := air.Spec.Policies["policy"]
+	policy.Spec.PolicyDocument = aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow",` +
+		`"Action":"iam:PassRole","Resource":"{{ .RoleARNs.role }}"}]}`)
+	air.Spec.Policies["policy"] = policy
+
+	documents, err = r.Render(ctx, air, RenderValues{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roleARN := fmt.Sprintf("arn:aws:iam::%s:role%s%s-role", FakeAccountID, aws_sdk.DefaultPathPrefix, clusterName)
+	if !strings.Contains(documents[2].Document, roleARN) {
+		t.Errorf("role ARN is not rendered: %s", documents[2].Document)
+	}
+
+	policy.Spec.PolicyDocument = aws.String(`{"Version":"2012-10-17",`)
+	air.Spec.Policies["policy"] = policy
+	if _, err := r.Render(ctx, air, RenderValues{}); err == nil || !strings.Contains(err.Error(), "policy policy") {
+		t.Errorf("expected the invalid policy document error, got %v", err)
+	}
+}
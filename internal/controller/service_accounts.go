@@ -0,0 +1,378 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+const (
+	// capiClusterNameLabel - the label of the Cluster API objects with the name of their Cluster.
+	capiClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+	// kubeconfigSecretKey - the key of the kubeconfig in the Secrets generated by Cluster API.
+	kubeconfigSecretKey = "value"
+	// roleARNAnnotation - the annotation of the ServiceAccount with the IAM role of IRSA.
+	roleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// serviceAccountOwnerAnnotation - the annotation of the ServiceAccount with the AWSIAMProvision which bound it.
+	serviceAccountOwnerAnnotation = "iam.aws.edenlab.io/awsiamprovision"
+)
+
+// errKubeconfigNotFound - the Secret with the kubeconfig of the workload cluster
+// or the AWSManagedControlPlane referring to it does not exist.
+var errKubeconfigNotFound = errors.New("kubeconfig of the workload cluster not found")
+
+// WorkloadClientFactory creates the client of the workload cluster from its kubeconfig.
+type WorkloadClientFactory func(kubeconfig []byte) (client.Client, error)
+
+// workloadClientCache - the clients of the workload clusters by their kubeconfig Secrets,
+// a client is recreated once the resourceVersion of its Secret changes.
+type workloadClientCache struct {
+	mu      sync.Mutex
+	clients map[string]cachedWorkloadClient
+}
+
+type cachedWorkloadClient struct {
+	client          client.Client
+	resourceVersion string
+}
+
+// get returns the cached client of the kubeconfig Secret or creates it by the factory.
+func (c *workloadClientCache) get(key string, secret *corev1.Secret, kubeconfig []byte,
+	newWorkloadClient WorkloadClientFactory) (client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	workloadClient, err := newWorkloadClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[string]cachedWorkloadClient)
+	}
+
+	c.clients[key] = cachedWorkloadClient{client: workloadClient, resourceVersion: secret.ResourceVersion}
+
+	return workloadClient, nil
+}
+
+// forget drops the client of the kubeconfig Secret which does not exist anymore.
+func (c *workloadClientCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.clients, key)
+}
+
+// NewWorkloadClient is the default WorkloadClientFactory.
+func NewWorkloadClient(kubeconfig []byte) (client.Client, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(config, client.Options{})
+}
+
+// getKubeconfigSecretRef returns the Secret with the kubeconfig of the workload cluster. By default, it is the Secret
+// of the Cluster of the AWSManagedControlPlane, or of the Cluster named as the EKS cluster for other cluster sources.
+func (rm *ReconciliationManager) getKubeconfigSecretRef(air *awsIAMResources) (*iamv1alpha1.KubeconfigSecretReference, error) {
+	if ref := air.awsIAMProvision.Spec.KubeconfigSecretRef; ref != nil {
+		secretRef := ref.DeepCopy()
+		if len(secretRef.Key) == 0 {
+			secretRef.Key = kubeconfigSecretKey
+		}
+
+		return secretRef, nil
+	}
+
+	clusterName := air.awsIAMProvision.Spec.EKSClusterName
+	if sourceType, _ := rm.getClusterSource(air); sourceType == iamv1alpha1.ClusterSourceCAPA {
+		eksCP := &ekscontrolplanev1.AWSManagedControlPlane{}
+		key := types.NamespacedName{Name: clusterName, Namespace: air.awsIAMProvision.Namespace}
+		if err := rm.Get(rm.ctx, key, eksCP); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: AWSManagedControlPlane %s", errKubeconfigNotFound, key)
+			}
+
+			return nil, err
+		}
+
+		if name, ok := eksCP.Labels[capiClusterNameLabel]; ok {
+			clusterName = name
+		}
+	}
+
+	return &iamv1alpha1.KubeconfigSecretReference{Name: clusterName + "-kubeconfig", Key: kubeconfigSecretKey}, nil
+}
+
+func (rm *ReconciliationManager) getWorkloadClient(air *awsIAMResources) (client.Client, error) {
+	secretRef, err := rm.getKubeconfigSecretRef(air)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: secretRef.Name, Namespace: air.awsIAMProvision.Namespace}
+	cacheKey := key.String() + "/" + secretRef.Key
+	if err := rm.apiReader().Get(rm.ctx, key, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			rm.workloadClients.forget(cacheKey)
+			return nil, fmt.Errorf("%w: Secret %s", errKubeconfigNotFound, key)
+		}
+
+		return nil, err
+	}
+
+	kubeconfig, ok := secret.Data[secretRef.Key]
+	if !ok {
+		return nil, fmt.Errorf("%w: key %s of Secret %s", errKubeconfigNotFound, secretRef.Key, key)
+	}
+
+	newWorkloadClient := rm.NewWorkloadClient
+	if newWorkloadClient == nil {
+		newWorkloadClient = NewWorkloadClient
+	}
+
+	return rm.workloadClients.get(cacheKey, secret, kubeconfig, newWorkloadClient)
+}
+
+// syncServiceAccounts annotates the ServiceAccounts of the roles in the workload cluster with the ARNs of the roles,
+// the ServiceAccounts which are not bound anymore are unbound. On deletion of the CR all of them are unbound.
+// The ServiceAccounts are not touched in the Observe mode.
+func (rm *ReconciliationManager) syncServiceAccounts(air *awsIAMResources, deleting bool) error {
+	if air.dryRun {
+		return nil
+	}
+
+	previous := air.awsIAMProvision.Status.ServiceAccounts
+	desired := make(map[types.NamespacedName]string)
+	// The ServiceAccounts of the roles owned by another CR are left as is.
+	skipped := make(map[types.NamespacedName]struct{})
+	if !deleting {
+		for _, key := range sortedMapKeys(air.spec.Roles) {
+			role := air.spec.Roles[key]
+			for _, serviceAccount := range role.ServiceAccounts {
+				name := types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}
+				if _, ok := desired[name]; ok {
+					return fmt.Errorf("ServiceAccount %s is bound to several roles of %s AWSIAMProvision",
+						name, rm.request.NamespacedName)
+				}
+
+				if air.hasConflict(air.conflictRoles, *role.Spec.Name) {
+					skipped[name] = struct{}{}
+					continue
+				}
+
+				desired[name] = string(air.templateData.RoleARNs[key])
+			}
+		}
+	}
+
+	if len(desired) == 0 && len(previous) == 0 {
+		return nil
+	}
+
+	workloadClient, err := rm.getWorkloadClient(air)
+	if err != nil {
+		// The workload cluster is deleted together with its kubeconfig, there is nothing to unbind.
+		if deleting && errors.Is(err, errKubeconfigNotFound) {
+			rm.logger.Info(fmt.Sprintf("ServiceAccounts were not unbound: %s", err))
+			air.awsIAMProvision.Status.ServiceAccounts = nil
+			air.statusChanged = true
+
+			return nil
+		}
+
+		return fmt.Errorf("unable to access the workload cluster of %s AWSIAMProvision: %w", rm.request.NamespacedName, err)
+	}
+
+	var (
+		errs   []error
+		status []iamv1alpha1.AWSIAMProvisionStatusServiceAccount
+	)
+
+	bound := make(map[types.NamespacedName]iamv1alpha1.AWSIAMProvisionStatusServiceAccount, len(previous))
+	for _, serviceAccount := range previous {
+		name := types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}
+		bound[name] = serviceAccount
+		if _, ok := skipped[name]; ok {
+			status = append(status, serviceAccount)
+			continue
+		}
+
+		if _, ok := desired[name]; ok {
+			continue
+		}
+
+		if err := rm.unbindServiceAccount(air, workloadClient, name, serviceAccount.Created); err != nil {
+			errs = append(errs, err)
+			status = append(status, serviceAccount)
+		}
+	}
+
+	for name, roleARN := range desired {
+		wasCreated, err := rm.bindServiceAccount(air, workloadClient, name, roleARN)
+		if err != nil {
+			errs = append(errs, err)
+			// The previous binding is kept, so the ServiceAccount created by the operator is still deleted on unbind.
+			if serviceAccount, ok := bound[name]; ok {
+				status = append(status, serviceAccount)
+			}
+
+			continue
+		}
+
+		status = append(status, iamv1alpha1.AWSIAMProvisionStatusServiceAccount{
+			Created:   bound[name].Created || wasCreated,
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			RoleARN:   roleARN,
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Namespace != status[j].Namespace {
+			return status[i].Namespace < status[j].Namespace
+		}
+
+		return status[i].Name < status[j].Name
+	})
+
+	if !equality.Semantic.DeepEqual(previous, status) {
+		air.awsIAMProvision.Status.ServiceAccounts = status
+		air.statusChanged = true
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// hasServiceAccounts reports whether the CR binds or has bound the ServiceAccounts.
+func hasServiceAccounts(air *awsIAMResources) bool {
+	for _, role := range air.awsIAMProvision.Spec.Roles {
+		if len(role.ServiceAccounts) > 0 {
+			return true
+		}
+	}
+
+	return len(air.awsIAMProvision.Status.ServiceAccounts) > 0
+}
+
+// serviceAccountOwner returns the value of the owner annotation of the ServiceAccounts bound by the CR.
+func serviceAccountOwner(air *awsIAMResources) string {
+	return air.awsIAMProvision.Namespace + "/" + air.awsIAMProvision.Name
+}
+
+// bindServiceAccount creates the ServiceAccount or annotates the existing one with the ARN of the role,
+// it reports whether the ServiceAccount was created.
+func (rm *ReconciliationManager) bindServiceAccount(air *awsIAMResources, workloadClient client.Client,
+	name types.NamespacedName, roleARN string) (bool, error) {
+	annotations := map[string]string{
+		roleARNAnnotation:             roleARN,
+		serviceAccountOwnerAnnotation: serviceAccountOwner(air),
+	}
+
+	serviceAccount := &corev1.ServiceAccount{}
+	if err := workloadClient.Get(rm.ctx, name, serviceAccount); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, fmt.Errorf("unable to get ServiceAccount %s: %w", name, err)
+		}
+
+		serviceAccount = &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace, Annotations: annotations},
+		}
+		if err := workloadClient.Create(rm.ctx, serviceAccount); err != nil {
+			return false, fmt.Errorf("unable to create ServiceAccount %s: %w", name, err)
+		}
+
+		rm.recordEvent(air, corev1.EventTypeNormal, eventReasonServiceAccountBound,
+			fmt.Sprintf("ServiceAccount %s was created for role %s.", name, roleARN))
+
+		return true, nil
+	}
+
+	if owner, ok := serviceAccount.Annotations[serviceAccountOwnerAnnotation]; ok && owner != serviceAccountOwner(air) {
+		return false, fmt.Errorf("ServiceAccount %s is bound by AWSIAMProvision %s", name, owner)
+	}
+
+	if serviceAccount.Annotations[roleARNAnnotation] == roleARN &&
+		serviceAccount.Annotations[serviceAccountOwnerAnnotation] == serviceAccountOwner(air) {
+		return false, nil
+	}
+
+	patch := client.MergeFrom(serviceAccount.DeepCopy())
+	if serviceAccount.Annotations == nil {
+		serviceAccount.Annotations = make(map[string]string)
+	}
+
+	for key, value := range annotations {
+		serviceAccount.Annotations[key] = value
+	}
+
+	if err := workloadClient.Patch(rm.ctx, serviceAccount, patch); err != nil {
+		return false, fmt.Errorf("unable to annotate ServiceAccount %s: %w", name, err)
+	}
+
+	rm.recordEvent(air, corev1.EventTypeNormal, eventReasonServiceAccountBound,
+		fmt.Sprintf("ServiceAccount %s was annotated with role %s.", name, roleARN))
+
+	return false, nil
+}
+
+// unbindServiceAccount deletes the ServiceAccount created by the operator or removes the annotations
+// from the existing one. The ServiceAccounts bound by another CR in the meantime are left as is.
+func (rm *ReconciliationManager) unbindServiceAccount(air *awsIAMResources, workloadClient client.Client,
+	name types.NamespacedName, created bool) error {
+	serviceAccount := &corev1.ServiceAccount{}
+	if err := workloadClient.Get(rm.ctx, name, serviceAccount); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to get ServiceAccount %s: %w", name, err)
+	}
+
+	if serviceAccount.Annotations[serviceAccountOwnerAnnotation] != serviceAccountOwner(air) {
+		return nil
+	}
+
+	if created {
+		if err := workloadClient.Delete(rm.ctx, serviceAccount); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete ServiceAccount %s: %w", name, err)
+		}
+
+		rm.recordEvent(air, corev1.EventTypeNormal, eventReasonServiceAccountUnbound,
+			fmt.Sprintf("ServiceAccount %s was deleted.", name))
+
+		return nil
+	}
+
+	patch := client.MergeFrom(serviceAccount.DeepCopy())
+	delete(serviceAccount.Annotations, roleARNAnnotation)
+	delete(serviceAccount.Annotations, serviceAccountOwnerAnnotation)
+	if err := workloadClient.Patch(rm.ctx, serviceAccount, patch); err != nil {
+		return fmt.Errorf("unable to remove the annotations of ServiceAccount %s: %w", name, err)
+	}
+
+	rm.recordEvent(air, corev1.EventTypeNormal, eventReasonServiceAccountUnbound,
+		fmt.Sprintf("The role annotation was removed from ServiceAccount %s.", name))
+
+	return nil
+}
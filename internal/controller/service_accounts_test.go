@@ -0,0 +1,212 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestReconcileServiceAccounts binds the ServiceAccounts of the workload cluster to the roles and unbinds them.
+func TestReconcileServiceAccounts(t *testing.T) {
+	const clusterName = "bound"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[0].SetLabels(map[string]string{capiClusterNameLabel: "capi-" + clusterName})
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	role := air.Spec.Roles["role"]
+	role.ServiceAccounts = []iamv1alpha1.ServiceAccountReference{
+		{Name: "existing", Namespace: "app"},
+		{Name: "created", Namespace: "app"},
+	}
+	air.Spec.Roles["role"] = role
+
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "capi-" + clusterName + "-kubeconfig", Namespace: testNamespace},
+		Data:       map[string][]byte{kubeconfigSecretKey: []byte("kubeconfig")},
+	}
+	workloadClient := fake.NewClientBuilder().WithObjects(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "app", Annotations: map[string]string{"keep": "true"}},
+	}).Build()
+
+	r := newTestReconciler(t, newFakeIAMManager(), append(objects, kubeconfig)...)
+	clients := 0
+	r.NewWorkloadClient = func(data []byte) (client.Client, error) {
+		if string(data) != "kubeconfig" {
+			return nil, fmt.Errorf("unexpected kubeconfig %q", data)
+		}
+
+		clients++
+
+		return workloadClient, nil
+	}
+
+	getServiceAccount := func(name string) (*corev1.ServiceAccount, error) {
+		serviceAccount := &corev1.ServiceAccount{}
+		err := workloadClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "app"}, serviceAccount)
+
+		return serviceAccount, err
+	}
+
+	mustReconcile(t, r, key)
+
+	roleARN := fmt.Sprintf("arn:aws:iam::%s:role%s%s-role", testAccountID, aws_sdk.DefaultPathPrefix, clusterName)
+	for _, name := range []string{"existing", "created"} {
+		serviceAccount, err := getServiceAccount(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if serviceAccount.Annotations[roleARNAnnotation] != roleARN {
+			t.Errorf("ServiceAccount %s is not annotated: %v", name, serviceAccount.Annotations)
+		}
+	}
+
+	mustGet(t, r, key, air)
+
+	if serviceAccounts := air.Status.ServiceAccounts; len(serviceAccounts) != 2 ||
+		!serviceAccounts[0].Created || serviceAccounts[1].Created {
+		t.Errorf("unexpected status of ServiceAccounts %+v", serviceAccounts)
+	}
+
+	role = air.Spec.Roles["role"]
+	role.ServiceAccounts = role.ServiceAccounts[1:]
+	air.Spec.Roles["role"] = role
+	if err := r.Update(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if clients != 1 {
+		t.Errorf("workload client of the unchanged kubeconfig expected to be reused, created %d times", clients)
+	}
+
+	existing, err := getServiceAccount("existing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := existing.Annotations[roleARNAnnotation]; ok || existing.Annotations["keep"] != "true" {
+		t.Errorf("unexpected annotations of the unbound ServiceAccount %v", existing.Annotations)
+	}
+
+	if err := r.Delete(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if _, err := getServiceAccount("created"); !k8serrors.IsNotFound(err) {
+		t.Errorf("ServiceAccount created by the operator is not deleted: %v", err)
+	}
+}
+
+// TestReconcileServiceAccountsBindError checks the failed rebinding keeps the ServiceAccount created by the operator
+// in status, so it is still deleted with the CR.
+func TestReconcileServiceAccountsBindError(t *testing.T) {
+	const clusterName = "bound"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	objects[0].SetLabels(map[string]string{capiClusterNameLabel: "capi-" + clusterName})
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	role := air.Spec.Roles["role"]
+	role.ServiceAccounts = []iamv1alpha1.ServiceAccountReference{{Name: "created", Namespace: "app"}}
+	air.Spec.Roles["role"] = role
+
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "capi-" + clusterName + "-kubeconfig", Namespace: testNamespace},
+		Data:       map[string][]byte{kubeconfigSecretKey: []byte("kubeconfig")},
+	}
+
+	var fail bool
+	workloadClient := interceptor.NewClient(fake.NewClientBuilder().Build(), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if fail {
+				return errors.New("workload cluster unavailable")
+			}
+
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	r := newTestReconciler(t, newFakeIAMManager(), append(objects, kubeconfig)...)
+	r.NewWorkloadClient = func([]byte) (client.Client, error) { return workloadClient, nil }
+
+	mustReconcile(t, r, key)
+
+	fail = true
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Fatal("unavailable workload cluster expected to fail the reconciliation")
+	}
+
+	mustGet(t, r, key, air)
+	if serviceAccounts := air.Status.ServiceAccounts; len(serviceAccounts) != 1 || !serviceAccounts[0].Created {
+		t.Errorf("ServiceAccount created by the operator is not kept in status: %+v", serviceAccounts)
+	}
+
+	fail = false
+	if err := r.Delete(ctx, air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	err := workloadClient.Get(ctx, types.NamespacedName{Name: "created", Namespace: "app"}, &corev1.ServiceAccount{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("ServiceAccount created by the operator is not deleted: %v", err)
+	}
+}
+
+// TestKubeconfigSecretRefControlPlaneNotFound checks the missing AWSManagedControlPlane is not silently replaced
+// by the Secret named as the EKS cluster.
+func TestKubeconfigSecretRefControlPlaneNotFound(t *testing.T) {
+	const clusterName = "missing"
+
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	r := newTestReconciler(t, newFakeIAMManager(), air)
+	rm := &ReconciliationManager{
+		AWSIAMProvisionReconciler: r,
+		ctx:                       context.Background(),
+		request:                   ctrl.Request{NamespacedName: testKey(clusterName)},
+	}
+
+	resources := newAWSIAMResources()
+	resources.awsIAMProvision = air
+	if _, err := rm.getKubeconfigSecretRef(resources); !errors.Is(err, errKubeconfigNotFound) {
+		t.Errorf("expected %v, got %v", errKubeconfigNotFound, err)
+	}
+}
@@ -5,27 +5,91 @@ import (
 	"time"
 
 	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/metrics"
 	iamType "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	// Controller phases
 	destroyIntermediatePhase   = "Destroying"
 	destroyPhase               = "Destroyed"
+	observePhase               = "Observed"
 	provisionIntermediatePhase = "Provisioning"
 	provisionPhase             = "Provisioned"
 
-	// AWS IAM resources phases
-	attachPhase = "Attached"
-	createPhase = "Created"
-	deletePhase = "Deleted"
-	detachPhase = "Detached"
-	failPhase   = "Failed"
-	updatePhase = "Updated"
+	// AWS IAM resources phases, they are also used as the reasons of the Kubernetes events
+	adoptPhase    = "Adopted"
+	attachPhase   = "Attached"
+	conflictPhase = "Conflict"
+	createPhase   = "Created"
+	deletePhase   = "Deleted"
+	detachPhase   = "Detached"
+	failPhase     = "Failed"
+	updatePhase   = "Updated"
+
+	// Kubernetes events reasons
+	eventReasonDriftDetected         = "DriftDetected"
+	eventReasonResourcesRetained     = "ResourcesRetained"
+	eventReasonServiceAccountBound   = "ServiceAccountBound"
+	eventReasonServiceAccountUnbound = "ServiceAccountUnbound"
 )
 
-func (rm *ReconciliationManager) updateCRDStatus(air *awsIAMResources, crdPhase, phase, message string, result interface{}) error {
+// mutationKinds maps the phases of AWS IAM resources to the kinds of the mutations metric.
+var mutationKinds = map[string]string{
+	adoptPhase:  "adopt",
+	attachPhase: "attach",
+	createPhase: "create",
+	deletePhase: "delete",
+	detachPhase: "detach",
+	updatePhase: "update",
+}
+
+func (rm *ReconciliationManager) recordEvent(air *awsIAMResources, eventType, reason, message string) {
+	// The mutations recorded in the Observe mode are published as the pending actions instead.
+	if _, ok := mutationKinds[reason]; ok && air.dryRun {
+		return
+	}
+
+	if kind, ok := mutationKinds[reason]; ok && eventType == corev1.EventTypeNormal {
+		metrics.IAMMutations.WithLabelValues(kind).Inc()
+	}
+
+	if rm.Recorder == nil {
+		return
+	}
+
+	rm.Recorder.Event(air.awsIAMProvision, eventType, reason, message)
+}
+
+// updateCRDStatus accumulates the status of the CR and of the role or policy returned by AWS IAM,
+// the status is written once by writeCRDStatus at the end of the reconciliation.
+// It is safe for concurrent use by the workers syncing the roles and policies.
+func (rm *ReconciliationManager) updateCRDStatus(air *awsIAMResources, crdPhase, phase, message string, result interface{}) {
+	air.mu.Lock()
+	defer air.mu.Unlock()
+
+	// The mutations recorded in the Observe mode are not applied, so they do not change the status of the resources.
+	if air.dryRun && len(phase) > 0 && phase != conflictPhase {
+		return
+	}
+
+	switch {
+	case phase == conflictPhase:
+		rm.recordEvent(air, corev1.EventTypeWarning, conflictPhase, message)
+	case len(phase) > 0:
+		rm.recordEvent(air, corev1.EventTypeNormal, phase, message)
+	case crdPhase == failPhase:
+		rm.recordEvent(air, corev1.EventTypeWarning, failPhase, message)
+	case crdPhase == destroyIntermediatePhase || crdPhase == destroyPhase:
+		rm.recordEvent(air, corev1.EventTypeNormal, crdPhase, message)
+	}
+
 	var (
 		ownerAccountID iamv1alpha1.AWSAccountID
 		region         iamv1alpha1.AWSRegion
@@ -38,12 +102,28 @@ func (rm *ReconciliationManager) updateCRDStatus(air *awsIAMResources, crdPhase,
 
 	switch r := result.(type) {
 	case *iamType.Role:
-		role, exists, err := rm.IAMClient.GetRoleByName(r.RoleName)
-		if err != nil {
-			return err
+		nums := make(map[bool]int)
+		for num, roleStatus := range air.awsIAMProvision.Status.Roles {
+			if *roleStatus.Name == *r.RoleName {
+				nums[true] = num
+				break
+			}
 		}
 
-		if exists {
+		num, found := nums[true]
+		switch {
+		case phase == deletePhase:
+			if found {
+				air.awsIAMProvision.Status.Roles = append(air.awsIAMProvision.Status.Roles[:num],
+					air.awsIAMProvision.Status.Roles[num+1:]...)
+			}
+		case r.Arn == nil:
+			// Only the name of the role is known, e.g. it was already deleted or the operation was skipped.
+			if found {
+				air.awsIAMProvision.Status.Roles[num].Message = message
+				air.awsIAMProvision.Status.Roles[num].Phase = phase
+			}
+		default:
 			arn := iamv1alpha1.AWSResourceName(*r.Arn)
 			awsIAMProvisionStatusRole := iamv1alpha1.AWSIAMProvisionStatusRole{
 				Name:    r.RoleName,
@@ -55,48 +135,52 @@ func (rm *ReconciliationManager) updateCRDStatus(air *awsIAMResources, crdPhase,
 						OwnerAccountID: &ownerAccountID,
 						Region:         &region,
 					},
-					CreateDate: &metav1.Time{Time: *r.CreateDate},
-					RoleID:     r.RoleId,
+					RoleID: r.RoleId,
 				},
 			}
 
-			nums := make(map[bool]int)
-			for num, roleStatus := range air.awsIAMProvision.Status.Roles {
-				if *roleStatus.Name == *role.RoleName {
-					nums[true] = num
-					break
-				}
+			if r.CreateDate != nil {
+				awsIAMProvisionStatusRole.Status.CreateDate = &metav1.Time{Time: *r.CreateDate}
+			}
+
+			if found {
+				awsIAMProvisionStatusRole.Conditions = air.awsIAMProvision.Status.Roles[num].Conditions
 			}
 
-			if num, ok := nums[true]; ok {
+			rm.setResourceConditions(air, &awsIAMProvisionStatusRole.Conditions, metrics.ResourceTypeRole, phase, message)
+
+			if found {
 				air.awsIAMProvision.Status.Roles[num] = awsIAMProvisionStatusRole
 			} else {
 				air.awsIAMProvision.Status.Roles = append(air.awsIAMProvision.Status.Roles, awsIAMProvisionStatusRole)
 			}
-		} else {
-			nums := make(map[bool]int)
-			for num, roleStatus := range air.awsIAMProvision.Status.Roles {
-				if *roleStatus.Name == *r.RoleName {
-					nums[true] = num
-					break
-				}
-			}
-
-			if num, ok := nums[true]; ok {
-				air.awsIAMProvision.Status.Roles = append(air.awsIAMProvision.Status.Roles[:num],
-					air.awsIAMProvision.Status.Roles[num+1:]...)
-			}
 		}
 	case *iamType.Policy:
-		policy, exists, err := rm.IAMClient.GetPolicyByName(r.PolicyName)
-		if err != nil {
-			return err
+		nums := make(map[bool]int)
+		for num, policyStatus := range air.awsIAMProvision.Status.Policies {
+			if *policyStatus.Name == *r.PolicyName {
+				nums[true] = num
+				break
+			}
 		}
 
-		if exists {
-			arn := iamv1alpha1.AWSResourceName(*policy.Arn)
+		num, found := nums[true]
+		switch {
+		case phase == deletePhase:
+			if found {
+				air.awsIAMProvision.Status.Policies = append(air.awsIAMProvision.Status.Policies[:num],
+					air.awsIAMProvision.Status.Policies[num+1:]...)
+			}
+		case r.Arn == nil:
+			// Only the name of the policy is known, e.g. it was detached from a role.
+			if found {
+				air.awsIAMProvision.Status.Policies[num].Message = message
+				air.awsIAMProvision.Status.Policies[num].Phase = phase
+			}
+		default:
+			arn := iamv1alpha1.AWSResourceName(*r.Arn)
 			awsIAMProvisionStatusPolicy := iamv1alpha1.AWSIAMProvisionStatusPolicy{
-				Name:    policy.PolicyName,
+				Name:    r.PolicyName,
 				Message: message,
 				Phase:   phase,
 				Status: iamv1alpha1.PolicyStatus{
@@ -105,49 +189,176 @@ func (rm *ReconciliationManager) updateCRDStatus(air *awsIAMResources, crdPhase,
 						OwnerAccountID: &ownerAccountID,
 						Region:         &region,
 					},
-					AttachmentCount:  policy.AttachmentCount,
-					DefaultVersionID: policy.DefaultVersionId,
-					CreateDate:       &metav1.Time{Time: *policy.CreateDate},
-					PolicyID:         policy.PolicyId,
+					AttachmentCount:  r.AttachmentCount,
+					DefaultVersionID: r.DefaultVersionId,
+					PolicyID:         r.PolicyId,
 				},
 			}
 
-			nums := make(map[bool]int)
-			for num, policyStatus := range air.awsIAMProvision.Status.Policies {
-				if *policyStatus.Name == *policy.PolicyName {
-					nums[true] = num
-					break
-				}
+			if r.CreateDate != nil {
+				awsIAMProvisionStatusPolicy.Status.CreateDate = &metav1.Time{Time: *r.CreateDate}
 			}
 
-			if num, ok := nums[true]; ok {
+			if found {
+				awsIAMProvisionStatusPolicy.Conditions = air.awsIAMProvision.Status.Policies[num].Conditions
+			}
+
+			rm.setResourceConditions(air, &awsIAMProvisionStatusPolicy.Conditions, metrics.ResourceTypePolicy, phase, message)
+
+			if found {
 				air.awsIAMProvision.Status.Policies[num] = awsIAMProvisionStatusPolicy
 			} else {
 				air.awsIAMProvision.Status.Policies = append(air.awsIAMProvision.Status.Policies, awsIAMProvisionStatusPolicy)
 			}
-		} else {
-			nums := make(map[bool]int)
-			for num, policyStatus := range air.awsIAMProvision.Status.Policies {
-				if *policyStatus.Name == *r.PolicyName {
-					nums[true] = num
-					break
-				}
-			}
-
-			if num, ok := nums[true]; ok {
-				air.awsIAMProvision.Status.Policies = append(air.awsIAMProvision.Status.Policies[:num],
-					air.awsIAMProvision.Status.Policies[num+1:]...)
-			}
 		}
 	}
 
 	air.awsIAMProvision.Status.LastUpdatedTime = &metav1.Time{Time: time.Now()}
 	air.awsIAMProvision.Status.Phase = crdPhase
 	air.awsIAMProvision.Status.Message = message
+	rm.setReadyCondition(air)
+	air.statusChanged = true
+}
+
+// writeCRDStatus writes the accumulated status of the CR with a single patch,
+// the patch is retried on conflict against the latest version of the CR.
+func (rm *ReconciliationManager) writeCRDStatus(air *awsIAMResources) error {
+	if !air.statusChanged {
+		return nil
+	}
+
+	status := air.awsIAMProvision.Status.DeepCopy()
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &iamv1alpha1.AWSIAMProvision{}
+		if err := rm.Get(rm.ctx, client.ObjectKeyFromObject(air.awsIAMProvision), latest); err != nil {
+			return err
+		}
+
+		patch := client.MergeFromWithOptions(latest.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		latest.Status = *status
+		if err := rm.Status().Patch(rm.ctx, latest, patch); err != nil {
+			return err
+		}
+
+		// The following updates of the object, e.g. the removal of the finalizer, are based on the patched version.
+		air.awsIAMProvision.ResourceVersion = latest.ResourceVersion
+
+		return nil
+	}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
 
-	if err := rm.Status().Update(rm.ctx, air.awsIAMProvision); err != nil {
 		return fmt.Errorf("unable to update status for CRD: %s, error: %s", air.awsIAMProvision.Name, err)
 	}
 
+	air.statusChanged = false
+
 	return nil
 }
+
+func (rm *ReconciliationManager) setCondition(air *awsIAMResources, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&air.awsIAMProvision.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: air.awsIAMProvision.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setReadyCondition derives the summary Ready condition from the rest of the conditions.
+func (rm *ReconciliationManager) setReadyCondition(air *awsIAMResources) {
+	if !air.awsIAMProvision.DeletionTimestamp.IsZero() {
+		rm.setCondition(air, iamv1alpha1.ConditionTypeReady, metav1.ConditionFalse,
+			iamv1alpha1.ConditionReasonDeleting, air.awsIAMProvision.Status.Message)
+		return
+	}
+
+	conditionTypes := []string{
+		iamv1alpha1.ConditionTypeControlPlaneReady,
+		iamv1alpha1.ConditionTypeCredentialsValid,
+		iamv1alpha1.ConditionTypeSynced,
+	}
+	// The AWS credentials of the ACK backend are validated by the ACK iam-controller.
+	if rm.getBackend(air) == iamv1alpha1.BackendACK {
+		conditionTypes = []string{iamv1alpha1.ConditionTypeControlPlaneReady, iamv1alpha1.ConditionTypeSynced}
+	}
+
+	for _, conditionType := range conditionTypes {
+		condition := meta.FindStatusCondition(air.awsIAMProvision.Status.Conditions, conditionType)
+		if condition == nil {
+			rm.setCondition(air, iamv1alpha1.ConditionTypeReady, metav1.ConditionFalse,
+				iamv1alpha1.ConditionReasonProvisioning, air.awsIAMProvision.Status.Message)
+			return
+		}
+
+		if condition.Status != metav1.ConditionTrue {
+			rm.setCondition(air, iamv1alpha1.ConditionTypeReady, metav1.ConditionFalse,
+				condition.Reason, condition.Message)
+			return
+		}
+	}
+
+	rm.setCondition(air, iamv1alpha1.ConditionTypeReady, metav1.ConditionTrue,
+		iamv1alpha1.ConditionReasonReady, "AWS IAM resources synced with the remote state.")
+}
+
+// setResourceConditions sets the conditions of a role or policy by the phase of the latest operation.
+// An operation applied while the spec and the rendered documents are unchanged since the last successful sync
+// is considered a drift correction, the changes of the variables, templates or cluster are not.
+func (rm *ReconciliationManager) setResourceConditions(air *awsIAMResources, conditions *[]metav1.Condition, resourceType, phase, message string) {
+	generation := air.awsIAMProvision.Generation
+	if phase == conflictPhase {
+		for _, conditionType := range []string{iamv1alpha1.ConditionTypeReady, iamv1alpha1.ConditionTypeSynced} {
+			meta.SetStatusCondition(conditions, metav1.Condition{
+				Type:               conditionType,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             iamv1alpha1.ConditionReasonConflict,
+				Message:            message,
+			})
+		}
+
+		return
+	}
+
+	reason := iamv1alpha1.ConditionReasonSynced
+	if len(phase) > 0 {
+		reason = phase
+	}
+
+	for _, conditionType := range []string{iamv1alpha1.ConditionTypeReady, iamv1alpha1.ConditionTypeSynced} {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	status := &air.awsIAMProvision.Status
+	if len(phase) > 0 && status.ObservedGeneration == generation &&
+		len(status.DocumentsHash) > 0 && status.DocumentsHash == air.documentsHash {
+		air.drifted = true
+		metrics.DriftDetections.WithLabelValues(resourceType).Inc()
+		rm.recordEvent(air, corev1.EventTypeWarning, eventReasonDriftDetected, message)
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               iamv1alpha1.ConditionTypeDrifted,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             iamv1alpha1.ConditionReasonDriftCorrected,
+			Message:            message,
+		})
+		rm.setCondition(air, iamv1alpha1.ConditionTypeDrifted, metav1.ConditionTrue,
+			iamv1alpha1.ConditionReasonDriftCorrected, message)
+	} else {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               iamv1alpha1.ConditionTypeDrifted,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             iamv1alpha1.ConditionReasonNoDrift,
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/v2/controlplane/eks/api/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// TestReconcileDrift checks only the changes of the remote state are reported as a drift,
+// the changes of the rendered documents are not.
+func TestReconcileDrift(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, newTestObjects(clusterName)...)
+	key := testKey(clusterName)
+	mustReconcile(t, r, key)
+
+	drifted := func() metav1.ConditionStatus {
+		t.Helper()
+
+		air := &iamv1alpha1.AWSIAMProvision{}
+		mustGet(t, r, key, air)
+		if len(air.Status.DocumentsHash) == 0 {
+			t.Fatal("documents hash not set")
+		}
+
+		condition := meta.FindStatusCondition(air.Status.Conditions, iamv1alpha1.ConditionTypeDrifted)
+		if condition == nil {
+			t.Fatal("Drifted condition not set")
+		}
+
+		return condition.Status
+	}
+
+	if status := drifted(); status != metav1.ConditionFalse {
+		t.Errorf("initial sync: expected no drift, got %s", status)
+	}
+
+	// The OIDC provider of the cluster changes the rendered trust of the role.
+	eksCP := &ekscontrolplanev1.AWSManagedControlPlane{}
+	mustGet(t, r, key, eksCP)
+	eksCP.Status.OIDCProvider.ARN = oidcProviderARN(clusterName + "-new")
+	if err := r.Update(context.Background(), eksCP); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	if status := drifted(); status != metav1.ConditionFalse {
+		t.Errorf("rendered documents changed: expected no drift, got %s", status)
+	}
+
+	// The policy detached out of band is a drift of the remote state.
+	iamManager.mu.Lock()
+	delete(iamManager.attached, clusterName+"-role")
+	iamManager.mu.Unlock()
+
+	mustReconcile(t, r, key)
+
+	if status := drifted(); status != metav1.ConditionTrue {
+		t.Errorf("policy detached: expected drift, got %s", status)
+	}
+}
+
+// TestReconcileEvents checks every mutation of AWS IAM is recorded as an event and the resync records none.
+func TestReconcileEvents(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, newTestObjects(clusterName)...)
+	recorder := record.NewFakeRecorder(100)
+	r.Recorder = recorder
+	key := testKey(clusterName)
+
+	events := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+
+		sort.Strings(events)
+
+		return events
+	}
+
+	mustReconcile(t, r, key)
+
+	expected := []string{
+		fmt.Sprintf("Normal %s Policy %s-policy was attached to role %s-reader.", attachPhase, clusterName, clusterName),
+		fmt.Sprintf("Normal %s Policy %s-policy was attached to role %s-role.", attachPhase, clusterName, clusterName),
+		fmt.Sprintf("Normal %s Policy %s-policy was created.", createPhase, clusterName),
+		fmt.Sprintf("Normal %s Role %s-reader was created.", createPhase, clusterName),
+		fmt.Sprintf("Normal %s Role %s-role was created.", createPhase, clusterName),
+	}
+	if got := events(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected events of the provisioning:\n%s", strings.Join(got, "\n"))
+	}
+
+	mustReconcile(t, r, key)
+
+	if got := events(); len(got) > 0 {
+		t.Errorf("unexpected events of the resync:\n%s", strings.Join(got, "\n"))
+	}
+
+	air := &iamv1alpha1.AWSIAMProvision{}
+	mustGet(t, r, key, air)
+	delete(air.Spec.Roles, "reader")
+	if err := r.Update(context.Background(), air); err != nil {
+		t.Fatal(err)
+	}
+
+	mustReconcile(t, r, key)
+
+	expected = []string{
+		fmt.Sprintf("Normal %s Role %s-reader was deleted.", deletePhase, clusterName),
+		fmt.Sprintf("Normal %s Policy %s-policy was detached from role %s-reader.", detachPhase, clusterName, clusterName),
+	}
+	if got := events(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected events of the cleanup:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// TestReconcileStatusWrites checks the status accumulated by the parallel workers is written by a single patch
+// and the resync of the unchanged resources does not write it.
+func TestReconcileStatusWrites(t *testing.T) {
+	const clusterName = "cluster-a"
+
+	r := newTestReconciler(t, newFakeIAMManager(), newTestObjects(clusterName)...)
+	patches := 0
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object,
+			patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			patches++
+			return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+		},
+		SubResourceUpdate: func(context.Context, client.Client, string, client.Object, ...client.SubResourceUpdateOption) error {
+			t.Error("status expected to be patched, not updated")
+			return nil
+		},
+	})
+
+	key := testKey(clusterName)
+	mustReconcile(t, r, key)
+
+	if patches != 1 {
+		t.Errorf("provisioning: expected a single status patch, got %d", patches)
+	}
+
+	patches = 0
+	mustReconcile(t, r, key)
+
+	if patches != 0 {
+		t.Errorf("resync: expected no status patches, got %d", patches)
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// templateString - the string value of the document templates, it is JSON-escaped when printed by the template,
+// so the value is safe to be placed into a JSON string of the document.
+type templateString string
+
+func (s templateString) String() string {
+	quoted, _ := json.Marshal(string(s))
+
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// MarshalJSON marshals the raw value, so it is not escaped twice by toJson.
+func (s templateString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
+
+// documentTemplateData - the values available to the templates of the trust relationship policy documents
+// of the roles and the documents of the policies.
+type documentTemplateData struct {
+	AccountID        templateString
+	ClusterName      templateString
+	Namespace        templateString
+	OIDCProviderARN  templateString
+	OIDCProviderName templateString
+	// Parameters - the values of the parameters of the IAMPolicyTemplate, only set for its document.
+	Parameters map[string]templateString
+	Partition  templateString
+	Region     templateString
+	// RoleARNs - the ARNs of the roles of the CR by the keys of the roles in the spec.
+	RoleARNs map[string]templateString
+	// Variables - the user-defined variables of the CR.
+	Variables map[string]templateString
+}
+
+// documentTemplateFuncs - the helper functions of the document templates, they are free of side effects.
+var documentTemplateFuncs = template.FuncMap{
+	"default": func(defaultValue, value interface{}) interface{} {
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			return defaultValue
+		}
+
+		return value
+	},
+	"join": func(sep string, values interface{}) (templateString, error) {
+		list := reflect.ValueOf(values)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return "", fmt.Errorf("join: %T is not a list", values)
+		}
+
+		items := make([]string, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			items = append(items, fmt.Sprint(rawTemplateValue(list.Index(i).Interface())))
+		}
+
+		return templateString(strings.Join(items, sep)), nil
+	},
+	// quote returns the JSON string of the value including the quotes.
+	"quote": func(value interface{}) string {
+		quoted, _ := json.Marshal(fmt.Sprint(rawTemplateValue(value)))
+
+		return string(quoted)
+	},
+	"toJson": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+
+		return string(data), err
+	},
+}
+
+func rawTemplateValue(value interface{}) interface{} {
+	if s, ok := value.(templateString); ok {
+		return string(s)
+	}
+
+	return value
+}
+
+// newDocumentTemplateData collects the template values of the CR, the IAM names of the spec must be resolved.
+func newDocumentTemplateData(air *awsIAMResources, metadata *aws_sdk.IAMClientMetadata) *documentTemplateData {
+	oidcProviderARN := air.cluster.OIDCProviderARN
+	if len(oidcProviderARN) == 0 && len(air.cluster.OIDCIssuerURL) > 0 {
+		oidcProviderARN = fmt.Sprintf("arn:%s:iam::%s:oidc-provider/%s", metadata.GetPartition(),
+			metadata.AccountID, strings.TrimPrefix(air.cluster.OIDCIssuerURL, "https://"))
+	}
+
+	_, oidcProviderName, _ := strings.Cut(oidcProviderARN, "/")
+
+	data := &documentTemplateData{
+		AccountID:        templateString(metadata.AccountID),
+		ClusterName:      templateString(air.awsIAMProvision.Spec.EKSClusterName),
+		Namespace:        templateString(air.awsIAMProvision.Namespace),
+		OIDCProviderARN:  templateString(oidcProviderARN),
+		OIDCProviderName: templateString(oidcProviderName),
+		Partition:        templateString(metadata.GetPartition()),
+		Region:           templateString(metadata.Region),
+		RoleARNs:         make(map[string]templateString, len(air.spec.Roles)),
+		Variables:        make(map[string]templateString, len(air.variables)),
+	}
+
+	for key, role := range air.spec.Roles {
+		data.RoleARNs[key] = templateString(metadata.RoleARN(*role.Spec.Name))
+	}
+
+	for name, value := range air.variables {
+		data.Variables[name] = templateString(value)
+	}
+
+	return data
+}
+
+// renderDocumentTemplate renders the document template, the missing keys of the maps are errors.
+func renderDocumentTemplate(document string, templateData *documentTemplateData) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(documentTemplateFuncs).Parse(document)
+	if err != nil {
+		return "", err
+	}
+
+	var tmplString bytes.Buffer
+	if err := tmpl.Execute(&tmplString, templateData); err != nil {
+		return "", err
+	}
+
+	return tmplString.String(), nil
+}
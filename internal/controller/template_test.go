@@ -0,0 +1,87 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+	"aws-iam-provisioner.operators.infra/internal/aws_sdk"
+)
+
+// TestRenderDocumentTemplate renders the template values and helpers of the documents.
+func TestRenderDocumentTemplate(t *testing.T) {
+	templateData := &documentTemplateData{
+		AccountID:   testAccountID,
+		ClusterName: `cluster"name`,
+		Partition:   "aws",
+		Region:      testRegion,
+		RoleARNs:    map[string]templateString{"role": "arn:aws:iam::111122223333:role/role"},
+	}
+
+	for _, tc := range []struct {
+		document string
+		expected string
+		err      bool
+	}{
+		{`"arn:{{ .Partition }}:s3:::{{ .ClusterName }}"`, `"arn:aws:s3:::cluster\"name"`, false},
+		{`{{ quote .ClusterName }}`, `"cluster\"name"`, false},
+		{`{{ toJson .RoleARNs }}`, `{"role":"arn:aws:iam::111122223333:role/role"}`, false},
+		{`"{{ .RoleARNs.role }}"`, `"arn:aws:iam::111122223333:role/role"`, false},
+		{`"{{ .RoleARNs.missing }}"`, ``, true},
+		{`"{{ .Missing }}"`, ``, true},
+		{`"{{ default "default" .Namespace }}"`, `"default"`, false},
+		{`"{{ default "default" .Region }}"`, `"` + testRegion + `"`, false},
+	} {
+		result, err := renderDocumentTemplate(tc.document, templateData)
+		if tc.err != (err != nil) {
+			t.Errorf("%s: unexpected error %v", tc.document, err)
+			continue
+		}
+
+		if result != tc.expected {
+			t.Errorf("%s: rendered %s, expected %s", tc.document, result, tc.expected)
+		}
+	}
+
+	result, err := documentTemplateFuncs["join"].(func(string, interface{}) (templateString, error))(
+		",", []templateString{"a", `b"`})
+	if err != nil || result.String() != `a,b\"` {
+		t.Errorf("join: rendered %s, error %v", result, err)
+	}
+}
+
+// TestNewDocumentTemplateDataPartition checks the partition and the role ARNs of the template data are the ones
+// of the caller identity which the operator builds the ARNs in.
+func TestNewDocumentTemplateDataPartition(t *testing.T) {
+	air := newAWSIAMResources()
+	air.awsIAMProvision = newTestObjects("cluster-a")[1].(*iamv1alpha1.AWSIAMProvision)
+	air.spec = &air.awsIAMProvision.Spec
+	air.cluster = &Cluster{OIDCProviderARN: oidcProviderARN("cluster-a")}
+
+	metadata := &aws_sdk.IAMClientMetadata{AccountID: testAccountID, Partition: "aws-cn",
+		PathPrefix: aws_sdk.DefaultPathPrefix, Region: "cn-north-1"}
+	data := newDocumentTemplateData(air, metadata)
+
+	if data.Partition != "aws-cn" {
+		t.Errorf("expected partition aws-cn, got %s", data.Partition)
+	}
+
+	if roleARN := data.RoleARNs["role"]; roleARN.String() != metadata.RoleARN("cluster-a-role") {
+		t.Errorf("expected role ARN %s, got %s", metadata.RoleARN("cluster-a-role"), roleARN)
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// defaultTrustAudience - the audience of the tokens of the ServiceAccounts projected for IRSA.
+const defaultTrustAudience = "sts.amazonaws.com"
+
+// compileTrustDocument compiles the structured trust of the role into the trust relationship policy document.
+// The ServiceAccounts assume the role with the web identity of the OIDC provider of the cluster,
+// the rest of the principals by their actions, the additional conditions apply to all the statements.
+func compileTrustDocument(trust *iamv1alpha1.RoleTrust, oidcProviderARN, oidcProviderName string) (string, error) {
+	newConditions := func() (map[string]map[string]policyValue, error) {
+		conditions := make(map[string]map[string]policyValue)
+		for _, condition := range trust.Conditions {
+			if err := addCondition(conditions, condition.Test, condition.Variable, condition.Values); err != nil {
+				return nil, err
+			}
+		}
+
+		return conditions, nil
+	}
+
+	document := &policyDocument{Version: policyDocumentVersion}
+	if len(trust.ServiceAccounts) > 0 {
+		conditions, err := newConditions()
+		if err != nil {
+			return "", err
+		}
+
+		// StringLike matches the exact subjects as well.
+		subjectTest := "StringEquals"
+		subjects := make(map[string]struct{}, len(trust.ServiceAccounts))
+		for _, serviceAccount := range trust.ServiceAccounts {
+			subject := fmt.Sprintf("system:serviceaccount:%s:%s", serviceAccount.Namespace, serviceAccount.Name)
+			if strings.ContainsAny(subject, "*?") {
+				subjectTest = "StringLike"
+			}
+
+			subjects[subject] = struct{}{}
+		}
+
+		audience := trust.Audience
+		if len(audience) == 0 {
+			audience = defaultTrustAudience
+		}
+
+		if err := addCondition(conditions, subjectTest, oidcProviderName+":sub", sortedMapKeys(subjects)); err != nil {
+			return "", err
+		}
+
+		if err := addCondition(conditions, "StringEquals", oidcProviderName+":aud", []string{audience}); err != nil {
+			return "", err
+		}
+
+		document.Statement = append(document.Statement, policyStatement{
+			Effect:    "Allow",
+			Principal: map[string]policyValue{"Federated": {oidcProviderARN}},
+			Action:    policyValue{"sts:AssumeRoleWithWebIdentity"},
+			Condition: conditions,
+		})
+	}
+
+	for _, principal := range trust.Principals {
+		conditions, err := newConditions()
+		if err != nil {
+			return "", err
+		}
+
+		actions := principal.Actions
+		if len(actions) == 0 {
+			actions = []string{"sts:AssumeRole"}
+		}
+
+		identifiers := append([]string{}, principal.Identifiers...)
+		sort.Strings(identifiers)
+		document.Statement = append(document.Statement, policyStatement{
+			Effect:    "Allow",
+			Principal: map[string]policyValue{principal.Type: identifiers},
+			Action:    actions,
+			Condition: conditions,
+		})
+	}
+
+	return marshalPolicyDocument(document)
+}
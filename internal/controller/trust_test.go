@@ -0,0 +1,81 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+func TestCompileTrustDocument(t *testing.T) {
+	arn := oidcProviderARN("cluster")
+	name := strings.TrimPrefix(arn, "arn:aws:iam::"+testAccountID+":oidc-provider/")
+
+	for _, tc := range []struct {
+		trust    iamv1alpha1.RoleTrust
+		expected string
+		err      bool
+	}{
+		{
+			iamv1alpha1.RoleTrust{ServiceAccounts: []iamv1alpha1.ServiceAccountReference{
+				{Namespace: "kube-system", Name: "b"}, {Namespace: "kube-system", Name: "a"}}},
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"` + arn + `"},` +
+				`"Action":"sts:AssumeRoleWithWebIdentity","Condition":{"StringEquals":{"` + name + `:aud":"sts.amazonaws.com",` +
+				`"` + name + `:sub":["system:serviceaccount:kube-system:a","system:serviceaccount:kube-system:b"]}}}]}`,
+			false,
+		},
+		{
+			iamv1alpha1.RoleTrust{Audience: "aud", ServiceAccounts: []iamv1alpha1.ServiceAccountReference{
+				{Namespace: "apps", Name: "*"}}},
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"` + arn + `"},` +
+				`"Action":"sts:AssumeRoleWithWebIdentity","Condition":{"StringEquals":{"` + name + `:aud":"aud"},` +
+				`"StringLike":{"` + name + `:sub":"system:serviceaccount:apps:*"}}}]}`,
+			false,
+		},
+		{
+			iamv1alpha1.RoleTrust{
+				Conditions: []iamv1alpha1.PolicyCondition{
+					{Test: "StringEquals", Variable: "aws:PrincipalAccount", Values: []string{testAccountID}}},
+				Principals: []iamv1alpha1.TrustPrincipal{{Type: "Service", Identifiers: []string{"ec2.amazonaws.com"}}},
+			},
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},` +
+				`"Action":"sts:AssumeRole","Condition":{"StringEquals":{"aws:PrincipalAccount":"` + testAccountID + `"}}}]}`,
+			false,
+		},
+		{
+			iamv1alpha1.RoleTrust{
+				Conditions: []iamv1alpha1.PolicyCondition{
+					{Test: "StringEquals", Variable: name + ":aud", Values: []string{"aud"}}},
+				ServiceAccounts: []iamv1alpha1.ServiceAccountReference{{Namespace: "apps", Name: "app"}},
+			},
+			``,
+			true,
+		},
+	} {
+		result, err := compileTrustDocument(&tc.trust, arn, name)
+		if tc.err != (err != nil) {
+			t.Errorf("%+v: unexpected error %v", tc.trust, err)
+			continue
+		}
+
+		if result != tc.expected {
+			t.Errorf("%+v: compiled %s, expected %s", tc.trust, result, tc.expected)
+		}
+	}
+}
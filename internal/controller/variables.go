@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// Field indexes of AWSIAMProvision by the names of the variablesFrom sources.
+const (
+	variablesFromConfigMapField = ".spec.variablesFrom.configMapRef.name"
+	variablesFromSecretField    = ".spec.variablesFrom.secretRef.name"
+)
+
+// resolveVariables merges the data of the variablesFrom sources in order and the variables of the spec.
+func (rm *ReconciliationManager) resolveVariables(air *awsIAMResources) error {
+	sources := make([]map[string]string, 0, len(air.awsIAMProvision.Spec.VariablesFrom)+1)
+	for _, source := range air.awsIAMProvision.Spec.VariablesFrom {
+		if rm.Client == nil {
+			return fmt.Errorf("variablesFrom of %s AWSIAMProvision can not be read without access to the cluster",
+				rm.request.NamespacedName)
+		}
+
+		data, err := rm.getVariablesSourceData(air, source)
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, data)
+	}
+
+	air.variables = mergeVariables(append(sources, air.awsIAMProvision.Spec.Variables)...)
+
+	return nil
+}
+
+func (rm *ReconciliationManager) getVariablesSourceData(air *awsIAMResources,
+	source iamv1alpha1.VariablesSource) (map[string]string, error) {
+	var (
+		kind      string
+		object    client.Object
+		reference *iamv1alpha1.VariablesSourceReference
+	)
+
+	switch {
+	case source.ConfigMapRef != nil:
+		kind, object, reference = "ConfigMap", &corev1.ConfigMap{}, source.ConfigMapRef
+	case source.SecretRef != nil:
+		kind, object, reference = "Secret", &corev1.Secret{}, source.SecretRef
+	default:
+		return nil, fmt.Errorf("variablesFrom of %s AWSIAMProvision has neither configMapRef nor secretRef",
+			rm.request.NamespacedName)
+	}
+
+	key := types.NamespacedName{Name: reference.Name, Namespace: air.awsIAMProvision.Namespace}
+	if err := rm.apiReader().Get(rm.ctx, key, object); err != nil {
+		if k8serrors.IsNotFound(err) && reference.Optional {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to get %s %s of variablesFrom of %s AWSIAMProvision: %w",
+			kind, key, rm.request.NamespacedName, err)
+	}
+
+	data := make(map[string]string)
+	switch obj := object.(type) {
+	case *corev1.ConfigMap:
+		maps.Copy(data, obj.Data)
+	case *corev1.Secret:
+		for name, value := range obj.Data {
+			data[name] = string(value)
+		}
+	}
+
+	return data, nil
+}
+
+// apiReader returns the reader of the ConfigMaps and Secrets, only their metadata is cached.
+func (r *AWSIAMProvisionReconciler) apiReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+
+	return r.APIReader
+}
+
+// mergeVariables merges the variables in order, the latter take precedence.
+func mergeVariables(sources ...map[string]string) map[string]string {
+	variables := make(map[string]string)
+	for _, source := range sources {
+		maps.Copy(variables, source)
+	}
+
+	return variables
+}
+
+// variablesFromIndexer indexes AWSIAMProvision by the names of the ConfigMaps or Secrets of variablesFrom.
+func variablesFromIndexer(secret bool) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		var names []string
+		for _, source := range obj.(*iamv1alpha1.AWSIAMProvision).Spec.VariablesFrom {
+			if reference := source.ConfigMapRef; !secret && reference != nil {
+				names = append(names, reference.Name)
+			}
+
+			if reference := source.SecretRef; secret && reference != nil {
+				names = append(names, reference.Name)
+			}
+		}
+
+		return names
+	}
+}
+
+// findAWSIAMProvisionsForVariablesSource maps the ConfigMap or Secret to the AWSIAMProvision CRs
+// which read the variables from it.
+func (r *AWSIAMProvisionReconciler) findAWSIAMProvisionsForVariablesSource(field string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		awsIAMProvisions := &iamv1alpha1.AWSIAMProvisionList{}
+		if err := r.List(ctx, awsIAMProvisions, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{field: obj.GetName()}); err != nil {
+			log.FromContext(ctx).Error(err, "unable to list AWSIAMProvision of variablesFrom source",
+				"source", client.ObjectKeyFromObject(obj))
+
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(awsIAMProvisions.Items))
+		for _, awsIAMProvision := range awsIAMProvisions.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&awsIAMProvision)})
+		}
+
+		return requests
+	}
+}
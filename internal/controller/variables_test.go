@@ -0,0 +1,76 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	iamv1alpha1 "aws-iam-provisioner.operators.infra/api/v1alpha1"
+)
+
+// TestReconcileVariables renders the policy document with the variables merged from the spec and variablesFrom.
+func TestReconcileVariables(t *testing.T) {
+	const clusterName = "variables"
+
+	ctx := context.Background()
+	key := testKey(clusterName)
+	objects := newTestObjects(clusterName)
+	air := objects[1].(*iamv1alpha1.AWSIAMProvision)
+	air.Spec.Variables = map[string]string{"bucket": "spec-bucket"}
+	air.Spec.VariablesFrom = []iamv1alpha1.VariablesSource{
+		{ConfigMapRef: &iamv1alpha1.VariablesSourceReference{Name: "variables"}},
+		{SecretRef: &iamv1alpha1.VariablesSourceReference{Name: "variables"}},
+		{ConfigMapRef: &iamv1alpha1.VariablesSourceReference{Name: "missing", Optional: true}},
+	}
+	policy := air.Spec.Policies["policy"]
+	policy.Spec.PolicyDocument = aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*",` +
+		`"Resource":["arn:aws:s3:::{{ .Variables.bucket }}","{{ .Variables.key }}","{{ .Variables.zone }}"]}]}`)
+	air.Spec.Policies["policy"] = policy
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "variables", Namespace: testNamespace},
+		Data:       map[string]string{"bucket": "config-map-bucket", "key": "config-map-key", "zone": "config-map-zone"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "variables", Namespace: testNamespace},
+		Data:       map[string][]byte{"key": []byte("secret-key")},
+	}
+
+	iamManager := newFakeIAMManager()
+	r := newTestReconciler(t, iamManager, append(objects, configMap, secret)...)
+	mustReconcile(t, r, key)
+
+	expected := `"Resource":["arn:aws:s3:::spec-bucket","secret-key","config-map-zone"]`
+	if document := iamManager.documents[clusterName+"-policy"]; !strings.Contains(document, expected) {
+		t.Errorf("variables are not rendered: %s", document)
+	}
+
+	if err := r.Delete(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Error("expected the error of the missing Secret")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains the Prometheus collectors of the operator,
+// they are registered in the controller-runtime metrics registry and exposed by the manager metrics endpoint.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "aws_iam_provisioner"
+
+	// Label values of the IAM API calls result
+	ResultError   = "error"
+	ResultSuccess = "success"
+
+	// Label values of the IAM resource types
+	ResourceTypePolicy = "policy"
+	ResourceTypeRole   = "role"
+)
+
+var (
+	// IAMAPICalls - number of the AWS IAM API calls by operation and result.
+	IAMAPICalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "iam_api_calls_total",
+		Help:      "Number of the AWS IAM API calls by operation and result.",
+	}, []string{"operation", "result"})
+
+	// IAMAPICallDuration - latency of the AWS IAM API calls by operation.
+	IAMAPICallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "iam_api_call_duration_seconds",
+		Help:      "Latency of the AWS IAM API calls by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// IAMMutations - number of the AWS IAM resources mutations by kind (create, attach, detach, update, delete).
+	IAMMutations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "iam_mutations_total",
+		Help:      "Number of the AWS IAM resources mutations by kind.",
+	}, []string{"kind"})
+
+	// DriftDetections - number of the detected drifts of the remote state by resource type.
+	DriftDetections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drift_detections_total",
+		Help:      "Number of the detected drifts of the remote state by resource type.",
+	}, []string{"resource_type"})
+
+	// LastSuccessfulSync - timestamp of the last successful sync per CR,
+	// the time since the last sync is calculated as `time() - aws_iam_provisioner_last_successful_sync_timestamp_seconds`.
+	LastSuccessfulSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_sync_timestamp_seconds",
+		Help:      "Timestamp of the last successful sync of the AWSIAMProvision with AWS IAM.",
+	}, []string{"namespace", "name"})
+
+	// ManagedResources - number of the managed roles and policies per cluster and CR.
+	ManagedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_resources",
+		Help:      "Number of the AWS IAM resources managed by the AWSIAMProvision by resource type.",
+	}, []string{"cluster", "namespace", "name", "resource_type"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		IAMAPICalls,
+		IAMAPICallDuration,
+		IAMMutations,
+		DriftDetections,
+		LastSuccessfulSync,
+		ManagedResources,
+	)
+}
+
+// DeleteCRMetrics removes the series of a deleted AWSIAMProvision.
+func DeleteCRMetrics(namespace, name string) {
+	LastSuccessfulSync.DeleteLabelValues(namespace, name)
+	ManagedResources.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2025 Edenlab
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// TestRegistered checks the collectors are exposed by the manager metrics endpoint.
+func TestRegistered(t *testing.T) {
+	IAMAPICalls.WithLabelValues("CreateRole", ResultSuccess).Inc()
+	IAMAPICallDuration.WithLabelValues("CreateRole").Observe(1)
+	IAMMutations.WithLabelValues("create").Inc()
+	DriftDetections.WithLabelValues(ResourceTypeRole).Inc()
+	LastSuccessfulSync.WithLabelValues("default", "registered").SetToCurrentTime()
+	ManagedResources.WithLabelValues("cluster", "default", "registered", ResourceTypeRole).Set(1)
+
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gathered := make(map[string]struct{})
+	for _, family := range families {
+		gathered[family.GetName()] = struct{}{}
+	}
+
+	for _, name := range []string{
+		"aws_iam_provisioner_iam_api_calls_total",
+		"aws_iam_provisioner_iam_api_call_duration_seconds",
+		"aws_iam_provisioner_iam_mutations_total",
+		"aws_iam_provisioner_drift_detections_total",
+		"aws_iam_provisioner_last_successful_sync_timestamp_seconds",
+		"aws_iam_provisioner_managed_resources",
+	} {
+		if _, ok := gathered[name]; !ok {
+			t.Errorf("metric %s is not registered", name)
+		}
+	}
+}
+
+// TestDeleteCRMetrics checks only the series of the deleted CR are removed.
+func TestDeleteCRMetrics(t *testing.T) {
+	LastSuccessfulSync.Reset()
+	ManagedResources.Reset()
+
+	for _, name := range []string{"deleted", "kept"} {
+		LastSuccessfulSync.WithLabelValues("default", name).SetToCurrentTime()
+		ManagedResources.WithLabelValues("cluster", "default", name, ResourceTypeRole).Set(2)
+		ManagedResources.WithLabelValues("cluster", "default", name, ResourceTypePolicy).Set(1)
+	}
+
+	DeleteCRMetrics("default", "deleted")
+
+	if count := testutil.CollectAndCount(LastSuccessfulSync); count != 1 {
+		t.Errorf("expected 1 last successful sync series, got %d", count)
+	}
+
+	if count := testutil.CollectAndCount(ManagedResources); count != 2 {
+		t.Errorf("expected 2 managed resources series, got %d", count)
+	}
+
+	if value := testutil.ToFloat64(ManagedResources.WithLabelValues("cluster", "default", "kept",
+		ResourceTypeRole)); value != 2 {
+		t.Errorf("expected 2 managed roles of the kept CR, got %v", value)
+	}
+}